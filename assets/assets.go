@@ -0,0 +1,8 @@
+package assets
+
+import _ "embed"
+
+// Default deck definition, see cards.json
+//
+//go:embed cards.json
+var Cards []byte
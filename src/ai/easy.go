@@ -0,0 +1,36 @@
+package ai
+
+import (
+	"math/rand"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+)
+
+// Plays any legal card and picks everything else at random
+type easy struct{}
+
+func (easy) pickCard(s *engine.State, me *engine.Player, playable []engine.Card) engine.Card {
+	return playable[rand.Intn(len(playable))]
+}
+
+func (easy) pickColor(s *engine.State, me *engine.Player) string {
+	colors := s.ActiveColors()
+	return colors[rand.Intn(len(colors))]
+}
+
+func (easy) pickTarget(s *engine.State, me *engine.Player) string {
+	for {
+		target := s.Players[rand.Intn(len(s.Players))]
+		if target != me {
+			return target.ID
+		}
+	}
+}
+
+func (easy) challenge(s *engine.State, me *engine.Player) bool {
+	return false
+}
+
+func (easy) catch() bool {
+	return false
+}
@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+)
+
+// Remembers every card that has been played and watches how close opponents are to winning
+type hard struct {
+	round  string              // Deck hash of the round the memory is about
+	played map[string]seenCard // Cards seen on the discard pile by ID, reshuffles don't make it forget
+}
+
+// Card as it was seen on the discard pile
+type seenCard struct {
+	card engine.Card
+	dark bool // UNO Flip side that was up
+}
+
+func (h *hard) pickCard(s *engine.State, me *engine.Player, playable []engine.Card) engine.Card {
+	h.remember(s)
+	seen := h.playedColors(s)
+
+	next := s.NextPlayer()
+	nextDanger := !s.Teammates(me, next) && len(next.Hand) <= 2
+	danger := closestOpponent(s, me) <= 2
+	// Reversing hands the turn to the previous player
+	previous := previousPlayer(s)
+	previousDanger := !s.Teammates(me, previous) && len(previous.Hand) <= 2
+
+	best := playable[0]
+	bestScore := -1 << 31
+	for _, card := range playable {
+		score := card.Points()
+		switch {
+		case nextDanger && card.Type != engine.NumberCard:
+			// Stop the next player from going out
+			score += 100
+		case card.IsWild() && len(me.Hand) > 2 && !danger:
+			// Wilds are worth more in hand until the end, unless someone is about to go out
+			score -= 100
+		}
+		if card.Type == engine.ReverseCard && previousDanger && len(s.Players) > 2 {
+			score -= 100
+		}
+		if s.Teammates(me, next) && card.DrawAmount() > 0 {
+			// Don't make a partner draw
+			score -= 50
+		}
+		// Colors that have mostly been played are harder for others to follow
+		score += seen[card.Color]
+
+		if score > bestScore {
+			best, bestScore = card, score
+		}
+	}
+	return best
+}
+
+func (h *hard) pickColor(s *engine.State, me *engine.Player) string {
+	h.remember(s)
+
+	counts := map[string]int{}
+	for _, card := range me.Hand {
+		counts[card.Color] += 10
+	}
+	for color, played := range h.playedColors(s) {
+		counts[color] += played
+	}
+
+	best := mostHeldColor(s, me)
+	for _, color := range s.ActiveColors() {
+		if counts[color] > counts[best] {
+			best = color
+		}
+	}
+	return best
+}
+
+func (h *hard) pickTarget(s *engine.State, me *engine.Player) string {
+	return smallestHand(s, me)
+}
+
+// The bigger the hand, the more likely it held the previous color
+func (h *hard) challenge(s *engine.State, me *engine.Player) bool {
+	h.remember(s)
+
+	challenged := s.Player(s.Challenge.Player)
+	if challenged == nil {
+		return false
+	}
+	// Most of the previous color is gone, the hand likely has none left
+	if h.playedColors(s)[s.Challenge.PreviousColor] >= 15 {
+		return false
+	}
+	return len(challenged.Hand) >= 6
+}
+
+func (h *hard) catch() bool {
+	return true
+}
+
+// Add the cards on the discard pile to the memory, a new round starts it over
+func (h *hard) remember(s *engine.State) {
+	if h.played == nil || h.round != s.DeckHash {
+		h.round = s.DeckHash
+		h.played = map[string]seenCard{}
+	}
+	for _, card := range s.DiscardPile {
+		if _, ok := h.played[card.ID]; !ok {
+			h.played[card.ID] = seenCard{card: card, dark: s.Dark}
+		}
+	}
+}
+
+// Number of cards of each color played this round, on the side that is up
+func (h *hard) playedColors(s *engine.State) map[string]int {
+	counts := map[string]int{}
+	for _, seen := range h.played {
+		face := seen.card.Face
+		if seen.dark != s.Dark && seen.card.Flipside != nil {
+			face = *seen.card.Flipside
+		}
+		if color := face.Color; color != "" {
+			counts[color]++
+		}
+	}
+	return counts
+}
+
+// Fewest cards any opponent holds
+func closestOpponent(s *engine.State, me *engine.Player) int {
+	fewest := -1
+	for _, player := range s.Players {
+		if player == me || s.Teammates(me, player) {
+			continue
+		}
+		if fewest < 0 || len(player.Hand) < fewest {
+			fewest = len(player.Hand)
+		}
+	}
+	return fewest
+}
+
+// Player who went before the current one
+func previousPlayer(s *engine.State) *engine.Player {
+	step := -1
+	if s.Reversed {
+		step = 1
+	}
+	count := len(s.Players)
+	return s.Players[((s.CurrentTurn+step)%count+count)%count]
+}
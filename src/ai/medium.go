@@ -0,0 +1,33 @@
+package ai
+
+import (
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+)
+
+// Keeps wilds for last and picks the color it holds the most of
+type medium struct{}
+
+func (medium) pickCard(s *engine.State, me *engine.Player, playable []engine.Card) engine.Card {
+	for _, card := range playable {
+		if !card.IsWild() {
+			return card
+		}
+	}
+	return playable[0]
+}
+
+func (medium) pickColor(s *engine.State, me *engine.Player) string {
+	return mostHeldColor(s, me)
+}
+
+func (medium) pickTarget(s *engine.State, me *engine.Player) string {
+	return smallestHand(s, me)
+}
+
+func (medium) challenge(s *engine.State, me *engine.Player) bool {
+	return false
+}
+
+func (medium) catch() bool {
+	return false
+}
@@ -0,0 +1,156 @@
+package ai
+
+import (
+	"math/rand"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+)
+
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Medium
+	Hard
+)
+
+var Difficulties = []Difficulty{Easy, Medium, Hard}
+
+func (d Difficulty) String() string {
+	switch d {
+	case Medium:
+		return "Medium"
+	case Hard:
+		return "Hard"
+	default:
+		return "Easy"
+	}
+}
+
+// Strategy decides what a computer player does
+type Strategy interface {
+	// Next action for the player, nil when they have nothing to do
+	Act(s *engine.State, player string) engine.Action
+	// Catch someone who didn't call UNO, nil to let it slide
+	Catch(s *engine.State, player string) engine.Action
+}
+
+// Choices a difficulty makes, the rest of the turn logic is shared
+type brain interface {
+	pickCard(s *engine.State, me *engine.Player, playable []engine.Card) engine.Card
+	pickColor(s *engine.State, me *engine.Player) string
+	pickTarget(s *engine.State, me *engine.Player) string
+	challenge(s *engine.State, me *engine.Player) bool
+	catch() bool
+}
+
+// Create the strategy for a difficulty
+func New(difficulty Difficulty) Strategy {
+	switch difficulty {
+	case Medium:
+		return &player{brain: medium{}}
+	case Hard:
+		return &player{brain: &hard{}}
+	default:
+		return &player{brain: easy{}}
+	}
+}
+
+type player struct {
+	brain brain
+}
+
+func (p *player) Act(s *engine.State, id string) engine.Action {
+	me := s.Player(id)
+	if me == nil || s.Phase == engine.OverPhase {
+		return nil
+	}
+
+	// Never forget to call UNO
+	if s.UnoPending == id {
+		return engine.CallUno{Player: id}
+	}
+
+	if s.Phase == engine.ChallengePhase {
+		if s.NextPlayer() != me {
+			return nil
+		}
+		return engine.Challenge{Player: id, Challenge: p.brain.challenge(s, me)}
+	}
+
+	if s.CurrentPlayer() != me {
+		return nil
+	}
+
+	switch s.Phase {
+	case engine.TurnPhase:
+		var playable []engine.Card
+		for _, card := range me.Hand {
+			if s.CanPlay(card) {
+				playable = append(playable, card)
+			}
+		}
+		if len(playable) == 0 {
+			return engine.DrawCard{Player: id}
+		}
+		return engine.PlayCard{Player: id, CardID: p.brain.pickCard(s, me, playable).ID}
+	case engine.KeepPhase:
+		if s.CanPlay(*s.Drawn) {
+			return engine.PlayCard{Player: id, CardID: s.Drawn.ID}
+		}
+		return engine.KeepCard{Player: id}
+	case engine.ColorPhase:
+		return engine.ChooseColor{Player: id, Color: p.brain.pickColor(s, me)}
+	case engine.SwapPhase:
+		return engine.SwapHands{Player: id, Target: p.brain.pickTarget(s, me)}
+	}
+	return nil
+}
+
+func (p *player) Catch(s *engine.State, id string) engine.Action {
+	if s.Uncalled == "" || s.Uncalled == id || !p.brain.catch() {
+		return nil
+	}
+	// Never catch a partner
+	if s.Teammates(s.Player(id), s.Player(s.Uncalled)) {
+		return nil
+	}
+	return engine.CatchUno{Player: id, Target: s.Uncalled}
+}
+
+// Color the player holds the most of, wilds don't count
+func mostHeldColor(s *engine.State, me *engine.Player) string {
+	counts := map[string]int{}
+	for _, card := range me.Hand {
+		if color := card.Color; color != "" {
+			counts[color]++
+		}
+	}
+
+	colors := s.ActiveColors()
+	best := colors[rand.Intn(len(colors))]
+	for _, color := range colors {
+		if counts[color] > counts[best] {
+			best = color
+		}
+	}
+	return best
+}
+
+// Opponent with the fewest cards
+func smallestHand(s *engine.State, me *engine.Player) string {
+	var target *engine.Player
+	for _, player := range s.Players {
+		if player == me {
+			continue
+		}
+		// Opponents go first, a partner is only picked when nobody else is left
+		if target != nil && s.Teammates(me, player) && !s.Teammates(me, target) {
+			continue
+		}
+		if target == nil || (s.Teammates(me, target) && !s.Teammates(me, player)) || len(player.Hand) < len(target.Hand) {
+			target = player
+		}
+	}
+	return target.ID
+}
@@ -8,12 +8,19 @@ import (
 
 	"github.com/Ranzz02/uno-discord-bot/src/commands"
 	"github.com/Ranzz02/uno-discord-bot/src/config"
+	"github.com/Ranzz02/uno-discord-bot/src/game"
+	"github.com/Ranzz02/uno-discord-bot/src/store"
 	"github.com/bwmarrin/discordgo"
 )
 
 var Bot *discordgo.Session
 
 func InitBot() {
+	// Games, stats and settings are kept across restarts
+	if err := store.Open(config.Conf.Database); err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+
 	var err error
 	Bot, err = discordgo.New("Bot " + config.Conf.Token)
 	if err != nil {
@@ -26,6 +33,8 @@ func InitBot() {
 	Bot.AddHandler(commands.ColorHandler)
 	Bot.AddHandler(commands.ChallengeHandler)
 	Bot.AddHandler(commands.KeepCard)
+	Bot.AddHandler(commands.SwapHandler)
+	Bot.AddHandler(commands.ModalHandler)
 
 	Bot.Identify.Intents = discordgo.IntentsAllWithoutPrivileged
 
@@ -43,6 +52,10 @@ func InitBot() {
 
 	commands.RegisterCommands(Bot, "")
 
+	// Pick up the games that were running before the restart
+	game.RestoreGames(Bot)
+	go game.Autosave()
+
 	log.Println("Bot is now running. Press CTRL+C to exit.")
 	gracefulShutdown()
 }
@@ -58,6 +71,10 @@ func gracefulShutdown() {
 
 	// Close the Discord session
 	log.Println("Shutting down bot...")
+	game.SaveGames()
+	if err := store.Close(); err != nil {
+		log.Printf("Error closing the database: %v", err)
+	}
 	if err := Bot.Close(); err != nil {
 		log.Printf("Error closing the connection: %v", err)
 	}
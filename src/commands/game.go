@@ -1,7 +1,9 @@
 package commands
 
 import (
+	"fmt"
 	"log"
+	"slices"
 	"strings"
 
 	"github.com/Ranzz02/uno-discord-bot/src/game"
@@ -17,25 +19,135 @@ func CommandHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	switch commandData.Name {
 	case StartCMD:
-		game := game.NewGame(i)
-		if game == nil {
-			s.ChannelMessageSend(i.ChannelID, "Error occurred while creating the lobby")
+		if len(commandData.Options) == 0 || fromDM(s, i) {
 			return
 		}
+		subcommand := commandData.Options[0]
 
-		// Send the lobby message
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Data: game.RenderEmbed(s),
+		switch subcommand.Name {
+		case StartSubCMD:
+			startGame(s, i, subcommand.Options)
+		case VerifySubCMD:
+			// Check a fair shuffle proof
+			var proof, commitment string
+			for _, option := range subcommand.Options {
+				switch option.Name {
+				case ProofOption:
+					proof = option.StringValue()
+				case CommitmentOption:
+					commitment = option.StringValue()
+				}
+			}
+			game.Verify(s, i, proof, commitment)
+		case ReplaySubCMD:
+			// Open a replay file
+			for _, option := range subcommand.Options {
+				if option.Name != FileOption || commandData.Resolved == nil {
+					continue
+				}
+				if attachment := commandData.Resolved.Attachments[option.Value.(string)]; attachment != nil {
+					game.ShowReplay(s, i, attachment.URL)
+				}
+			}
+		case StatsSubCMD:
+			game.ShowStats(s, i, optionUser(i, subcommand.Options))
+		case LeaderboardSubCMD:
+			game.ShowLeaderboard(s, i)
+		case AchievementsSubCMD:
+			game.ShowAchievements(s, i, optionUser(i, subcommand.Options))
+		case SettingsSubCMD:
+			// Upload a custom deck with the menu
+			deckURL := ""
+			for _, option := range subcommand.Options {
+				if option.Name != DeckOption || commandData.Resolved == nil {
+					continue
+				}
+				if attachment := commandData.Resolved.Attachments[option.Value.(string)]; attachment != nil {
+					deckURL = attachment.URL
+				}
+			}
+			game.ShowSettings(s, i, deckURL)
+		case HandSubCMD, StatusSubCMD, EndSubCMD, KickSubCMD, TransferHostSubCMD, LeaveSubCMD, SeedSubCMD:
+			manageGame(s, i, subcommand)
+		}
+	case HelpCMD:
+		game.ShowHelp(s, i)
+	}
+}
+
+// User picked in the user option, the one who used the command if empty
+func optionUser(i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	for _, option := range options {
+		if option.Name == UserOption {
+			return option.UserValue(nil).ID
+		}
+	}
+	return i.Member.User.ID
+}
+
+// Run a subcommand on the channels game, works after the game message buttons expired
+func manageGame(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) {
+	g := game.FindGame(i.ChannelID)
+	if g == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "There is no game in this channel, start one with `/uno start`.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 		})
-		if err != nil {
-			log.Printf("Failed to send embed: %v", err)
-			s.ChannelMessageSend(i.ChannelID, "Error occurred while creating the lobby: "+err.Error())
-			return
+		return
+	}
+
+	switch subcommand.Name {
+	case HandSubCMD:
+		g.ShowHand(s, i)
+	case StatusSubCMD:
+		g.ShowStatus(s, i)
+	case EndSubCMD:
+		g.Stop(s, i)
+	case KickSubCMD:
+		g.Kick(s, i, optionUser(i, subcommand.Options))
+	case TransferHostSubCMD:
+		g.TransferHost(s, i, optionUser(i, subcommand.Options))
+	case LeaveSubCMD:
+		g.Leave(s, i)
+	case SeedSubCMD:
+		for _, option := range subcommand.Options {
+			if option.Name == TextOption {
+				g.AddSeed(s, i, option.StringValue())
+			}
 		}
 	}
 }
 
+// Create a lobby in the channel
+func startGame(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	game, err := game.NewGame(i, options)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "Can't start a game, " + err.Error() + ".",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	// Send the lobby message
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: game.RenderEmbed(s),
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Failed to send embed: %v", err)
+		s.ChannelMessageSend(i.ChannelID, "Error occurred while creating the lobby: "+err.Error())
+		return
+	}
+	game.SendJoinCode(s, i)
+}
+
 func ButtonHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if i.Type != discordgo.InteractionMessageComponent {
 		return
@@ -43,6 +155,24 @@ func ButtonHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	data := i.MessageComponentData()
 
+	// Replays, leaderboards, settings and help don't belong to the channels game
+	switch {
+	case game.IsReplayButton(data.CustomID):
+		game.StepReplay(s, i, data.CustomID)
+		return
+	case game.IsLeaderboardButton(data.CustomID):
+		game.LeaderboardPage(s, i, data.CustomID)
+		return
+	case game.IsSettingsComponent(data.CustomID):
+		game.ChangeSettings(s, i, data)
+		return
+	case game.IsHelpComponent(data.CustomID):
+		game.HelpPage(s, i, data)
+		return
+	case fromDM(s, i):
+		return
+	}
+
 	g := game.FindGame(i.ChannelID)
 	if g == nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -71,8 +201,31 @@ func ButtonHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		g.Delete(s, i)
 	case data.CustomID == game.UNOButton:
 		// Call UNO
+		g.CallUNO(s, i, false)
+	case data.CustomID == game.CatchButton:
+		// Catch player who didn't call UNO
+		g.CallUNO(s, i, true)
 	case data.CustomID == game.ReplayButton:
 		// Replay button
+		g.Rematch(s, i)
+	case data.CustomID == game.RulesSelect:
+		// Pick house rules
+		g.SetRules(s, i, data.Values)
+	case data.CustomID == game.ScoringSelect:
+		// Pick match target score
+		g.SetTargetScore(s, i, data.Values)
+	case data.CustomID == game.TimerSelect:
+		// Pick turn timer
+		g.SetTurnTimeout(s, i, data.Values)
+	case data.CustomID == game.AddBotSelect && len(data.Values) > 0 && data.Values[0] == game.RemoveBotOption:
+		// Remove computer player
+		g.RemoveBot(s, i)
+	case data.CustomID == game.AddBotSelect:
+		// Add computer player
+		g.AddBot(s, i, data.Values)
+	case data.CustomID == game.SwitchTeamButton:
+		// Move to the next team
+		g.SwitchTeam(s, i)
 	case data.CustomID == game.ViewCardsButton:
 		// Create a view of players hand
 		g.ViewCards(s, i)
@@ -84,21 +237,17 @@ func ButtonHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		cardID := strings.TrimPrefix(data.CustomID, "card-")
 		g.PlayCard(s, i, cardID)
 	case data.CustomID == game.PreviousButton: // Previous hand
-		player := g.GetPlayer(i.Member.User.ID)
-		if player != nil && player.Page > 0 {
-			player.Page--
+		if g.TurnPage(i.Member.User.ID, -1) {
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseUpdateMessage,
-				Data: g.RenderPlayerHand(player.User.ID),
+				Data: g.RenderPlayerHand(i.Member.User.ID),
 			})
 		}
 	case data.CustomID == game.NextButton: // Next hand
-		player := g.GetPlayer(i.Member.User.ID)
-		if player != nil && player.Page < game.MAX_CARDS_PER_PAGE-1 {
-			player.Page++
+		if g.TurnPage(i.Member.User.ID, 1) {
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseUpdateMessage,
-				Data: g.RenderPlayerHand(player.User.ID),
+				Data: g.RenderPlayerHand(i.Member.User.ID),
 			})
 		}
 	default:
@@ -119,6 +268,11 @@ func ColorHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	data := i.MessageComponentData()
 
+	// Replays, leaderboards, settings and help are answered by ButtonHandler, DMs too
+	if game.IsStandaloneButton(data.CustomID) || i.Member == nil {
+		return
+	}
+
 	g := game.FindGame(i.ChannelID)
 	if g == nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -135,18 +289,9 @@ func ColorHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	var selectedColor string
-	switch data.CustomID {
-	case "color_red":
-		selectedColor = "red"
-	case "color_green":
-		selectedColor = "green"
-	case "color_blue":
-		selectedColor = "blue"
-	case "color_yellow":
-		selectedColor = "yellow"
-	default:
-		// If the reaction is not valid, ignore it
+	// Only the colors of the side that is up can be picked
+	selectedColor := strings.TrimPrefix(data.CustomID, "color_")
+	if selectedColor == data.CustomID || !slices.Contains(g.Engine.ActiveColors(), selectedColor) {
 		return
 	}
 
@@ -162,6 +307,11 @@ func ChallengeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	data := i.MessageComponentData()
 
+	// Replays, leaderboards, settings and help are answered by ButtonHandler, DMs too
+	if game.IsStandaloneButton(data.CustomID) || i.Member == nil {
+		return
+	}
+
 	g := game.FindGame(i.ChannelID)
 	if g == nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -201,6 +351,11 @@ func KeepCard(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	data := i.MessageComponentData()
 
+	// Replays, leaderboards, settings and help are answered by ButtonHandler, DMs too
+	if game.IsStandaloneButton(data.CustomID) || i.Member == nil {
+		return
+	}
+
 	g := game.FindGame(i.ChannelID)
 	if g == nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -226,3 +381,84 @@ func KeepCard(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 }
+
+func SwapHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+
+	data := i.MessageComponentData()
+	if data.CustomID != game.SwapSelect || len(data.Values) == 0 || i.Member == nil {
+		return
+	}
+
+	g := game.FindGame(i.ChannelID)
+	if g == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "Game ended or crashed, start a new one.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	if g.SwapData.User != i.Member.User.ID {
+		return
+	}
+
+	g.SwapData.SwapResponse <- data.Values[0]
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("Swapping hands with %s!", g.Mention(data.Values[0])),
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		},
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
+
+func ModalHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionModalSubmit {
+		return
+	}
+
+	data := i.ModalSubmitData()
+	switch data.CustomID {
+	case game.SettingsLimitsModal:
+		game.ChangeLimits(s, i, data)
+	case game.JoinCodeModal:
+		if fromDM(s, i) {
+			return
+		}
+		g := game.FindGame(i.ChannelID)
+		if g == nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Data: &discordgo.InteractionResponseData{
+					Content: "Game ended or crashed, start a new one.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+			})
+			return
+		}
+		g.JoinWithCode(s, i, data)
+	}
+}
+
+// Games are played by server members, tell whoever tries it in a DM
+func fromDM(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	if i.Member != nil {
+		return false
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Content: "UNO is played in server channels, DMs have no game.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+	return true
+}
@@ -1,21 +1,240 @@
 package commands
 
 import (
+	"fmt"
 	"log"
+	"time"
 
+	"github.com/Ranzz02/uno-discord-bot/src/game"
 	"github.com/bwmarrin/discordgo"
 )
 
 const (
 	StartCMD string = "uno"
 	HelpCMD  string = "help"
+	// /uno subcommands
+	StartSubCMD        string = "start"
+	VerifySubCMD       string = "verify"
+	ReplaySubCMD       string = "replay"
+	StatsSubCMD        string = "stats"
+	LeaderboardSubCMD  string = "leaderboard"
+	AchievementsSubCMD string = "achievements"
+	SettingsSubCMD     string = "settings"
+	HandSubCMD         string = "hand"
+	StatusSubCMD       string = "status"
+	EndSubCMD          string = "end"
+	KickSubCMD         string = "kick"
+	TransferHostSubCMD string = "transfer-host"
+	LeaveSubCMD        string = "leave"
+	SeedSubCMD         string = "seed"
+	// Option names
+	ProofOption      string = "proof"
+	CommitmentOption string = "commitment"
+	TextOption       string = "text"
+	FileOption       string = "file"
+	UserOption       string = "user"
+	DeckOption       string = "deck"
+)
+
+var (
+	minSeed       float64 = 0
+	minHandSize   float64 = 1
+	minMaxPlayers float64 = 2
+	// Games need a server member to play as, /help works anywhere
+	dmPermission bool = false
 )
 
 var (
 	Commands = []*discordgo.ApplicationCommand{
 		{
-			Name:        StartCMD,
-			Description: "Start a new uno game",
+			Name:         StartCMD,
+			Description:  "Play uno",
+			DMPermission: &dmPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        StartSubCMD,
+					Description: "Start a new uno game",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        game.SeedOption,
+							Description: "Shuffle seed, the same seed deals the same cards",
+							MinValue:    &minSeed,
+							MaxValue:    float64(game.MAX_SEED),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        game.HandSizeOption,
+							Description: "Cards dealt to every player",
+							MinValue:    &minHandSize,
+							MaxValue:    float64(game.MAX_HAND_SIZE),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        game.RulesOption,
+							Description: "House rules separated by commas, e.g. stacking, seven-o, jump-in or official",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        game.TurnTimerOption,
+							Description: "Time every player has for their turn",
+							Choices:     turnTimerChoices(),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        game.MaxPlayersOption,
+							Description: "Most players that can join, bots included",
+							MinValue:    &minMaxPlayers,
+							MaxValue:    float64(game.MAX_PLAYERS),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        game.ScoringOption,
+							Description: "Play a single round or a match to a score",
+							Choices:     scoringChoices(),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        game.PrivateOption,
+							Description: "Only players with the join code can join",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        VerifySubCMD,
+					Description: "Check the fair shuffle proof from a finished game",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        ProofOption,
+							Description: "Proof from the end screen",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        CommitmentOption,
+							Description: "Commitment shown while the game was played",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        ReplaySubCMD,
+					Description: "Step through a finished game",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        FileOption,
+							Description: "Replay file from the end screen",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        StatsSubCMD,
+					Description: "Show uno stats on this server",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        UserOption,
+							Description: "Player to show, yourself if empty",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        LeaderboardSubCMD,
+					Description: "Show the best uno players on this server",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        AchievementsSubCMD,
+					Description: "Show earned and locked uno achievements on this server",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        UserOption,
+							Description: "Player to show, yourself if empty",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        SettingsSubCMD,
+					Description: "Change the uno defaults of this server, for server managers",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        DeckOption,
+							Description: "Deck definition JSON new lobbies play with, like assets/cards.json",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        HandSubCMD,
+					Description: "Show your cards in the game in this channel",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        StatusSubCMD,
+					Description: "Post the game in this channel again with working buttons",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        EndSubCMD,
+					Description: "End the game in this channel, for the host and server managers",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        KickSubCMD,
+					Description: "Remove a player from the game in this channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        UserOption,
+							Description: "Player to remove",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        TransferHostSubCMD,
+					Description: "Make another player the host of the game in this channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        UserOption,
+							Description: "Player to become the host",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        LeaveSubCMD,
+					Description: "Leave the game in this channel",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        SeedSubCMD,
+					Description: "Mix your own random text into the fair shuffle of the lobby in this channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        TextOption,
+							Description: "Anything random, only a hash of it is shown",
+							Required:    true,
+							MaxLength:   100,
+						},
+					},
+				},
+			},
 		},
 		{
 			Name:        HelpCMD,
@@ -24,6 +243,41 @@ var (
 	}
 )
 
+// Turn timers the host can pick from, in seconds
+func turnTimerChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, timeout := range game.TurnTimeouts {
+		name := fmt.Sprintf("%d seconds", int(timeout.Seconds()))
+		if timeout > time.Minute {
+			name = fmt.Sprintf("%d minutes", int(timeout.Minutes()))
+		}
+		if timeout == 0 {
+			name = "No turn timer"
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: int(timeout.Seconds()),
+		})
+	}
+	return choices
+}
+
+// Match targets the host can pick from
+func scoringChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, target := range game.TargetScores {
+		name := fmt.Sprintf("Match, first to %d points", target)
+		if target == 0 {
+			name = "Single round"
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: target,
+		})
+	}
+	return choices
+}
+
 func RegisterCommands(s *discordgo.Session, guildID string) {
 	created, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, guildID, Commands)
 	if err != nil {
@@ -10,7 +10,8 @@ import (
 var Conf *Config
 
 type Config struct {
-	Token string `env:"DISCORD_TOKEN,required"`
+	Token    string `env:"DISCORD_TOKEN,required"`
+	Database string `env:"DATABASE_FILE" envDefault:"uno.db"` // Games, stats and settings that survive a restart
 }
 
 func NewConf() {
@@ -0,0 +1,412 @@
+package engine
+
+// Action is a move made by a player
+type Action interface {
+	actor() string
+}
+
+// Play a card from hand
+type PlayCard struct {
+	Player string
+	CardID string
+}
+
+// Draw a card from the deck
+type DrawCard struct {
+	Player string
+}
+
+// Keep the card that was just drawn
+type KeepCard struct {
+	Player string
+}
+
+// Pick the color after playing a wild card
+type ChooseColor struct {
+	Player string
+	Color  string
+}
+
+// Swap hands with another player after playing a 7
+type SwapHands struct {
+	Player string
+	Target string
+}
+
+// Call UNO when down to one card
+type CallUno struct {
+	Player string
+}
+
+// Catch a player who didn't call UNO
+type CatchUno struct {
+	Player string
+	Target string
+}
+
+// Time to call UNO ran out, the player can be caught until the next player acts
+type ExpireUno struct {
+	Player string
+}
+
+// Answer a Wild Draw Four, Challenge is false to accept the cards
+type Challenge struct {
+	Player    string
+	Challenge bool
+}
+
+func (a PlayCard) actor() string    { return a.Player }
+func (a DrawCard) actor() string    { return a.Player }
+func (a KeepCard) actor() string    { return a.Player }
+func (a ChooseColor) actor() string { return a.Player }
+func (a SwapHands) actor() string   { return a.Player }
+func (a CallUno) actor() string     { return a.Player }
+func (a CatchUno) actor() string    { return a.Player }
+func (a ExpireUno) actor() string   { return a.Player }
+func (a Challenge) actor() string   { return a.Player }
+
+// Apply an action to the state and return what happened.
+// The state is left untouched when an error is returned.
+func (s *State) Apply(action Action) ([]Event, error) {
+	if s.Phase == OverPhase {
+		return nil, ErrGameOver
+	}
+	if s.Player(action.actor()) == nil {
+		return nil, ErrUnknownPlayer
+	}
+
+	uncalled := s.Uncalled
+	var events []Event
+	var err error
+	switch a := action.(type) {
+	case PlayCard:
+		events, err = s.playCard(a)
+	case DrawCard:
+		events, err = s.drawCard(a)
+	case KeepCard:
+		events, err = s.keepCard(a)
+	case ChooseColor:
+		events, err = s.chooseColor(a)
+	case Challenge:
+		events, err = s.challenge(a)
+	case SwapHands:
+		events, err = s.swapHands(a)
+	case CallUno:
+		events, err = s.callUno(a)
+	case CatchUno:
+		events, err = s.catchUno(a)
+	case ExpireUno:
+		events, err = s.expireUno(a)
+	default:
+		return nil, ErrWrongPhase
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing to call once the hand isn't down to one card anymore
+	if pending := s.Player(s.UnoPending); pending != nil && len(pending.Hand) != 1 {
+		s.UnoPending = ""
+	}
+	if missed := s.Player(s.Uncalled); missed != nil && len(missed.Hand) != 1 {
+		s.Uncalled = ""
+	}
+
+	// Too late to catch someone once the next player acts
+	switch action.(type) {
+	case CallUno, CatchUno, ExpireUno:
+	default:
+		if uncalled != "" && s.Uncalled == uncalled && action.actor() != uncalled {
+			s.Uncalled = ""
+		}
+	}
+	return events, nil
+}
+
+// Tries to play a players card on their turn, or out of turn as a jump-in
+func (s *State) playCard(a PlayCard) ([]Event, error) {
+	var events []Event
+
+	player := s.CurrentPlayer()
+	jumpIn := player.ID != a.Player
+	if jumpIn {
+		player = s.Player(a.Player)
+		index := player.cardIndex(a.CardID)
+		if index < 0 || !s.CanJumpIn(player.Hand[index]) {
+			return nil, ErrNotYourTurn
+		}
+	}
+
+	switch s.Phase {
+	case TurnPhase:
+	case KeepPhase:
+		// Only the drawn card can be played
+		if s.Drawn == nil || s.Drawn.ID != a.CardID {
+			return nil, ErrWrongPhase
+		}
+	default:
+		return nil, ErrWrongPhase
+	}
+
+	index := player.cardIndex(a.CardID)
+	if index < 0 {
+		return nil, ErrCardNotInHand
+	}
+	if !s.CanPlay(player.Hand[index]) {
+		return nil, ErrCannotPlay
+	}
+
+	if jumpIn {
+		// Turn order continues from the jumper, only once the card is known to be playable
+		s.CurrentTurn = s.seat(player)
+		events = append(events, JumpedIn{Player: player.ID})
+	}
+
+	card := player.removeCard(index)
+	previousColor := s.Color
+	s.DiscardPile = append(s.DiscardPile, card)
+	s.Color = card.Color
+	s.Drawn = nil
+	s.Phase = TurnPhase
+
+	events = append(events, CardPlayed{Player: player.ID, Card: card})
+
+	if len(player.Hand) == 1 {
+		s.UnoPending = player.ID
+	}
+
+	if len(player.Hand) == 0 {
+		s.Phase = OverPhase
+		s.Winner = player
+		return append(events, GameWon{Player: player.ID}), nil
+	}
+
+	switch card.Type {
+	case NumberCard:
+		if s.Rules.SevenO && card.Value == "7" {
+			s.Phase = SwapPhase
+			break
+		}
+		if s.Rules.SevenO && card.Value == "0" {
+			events = append(events, s.rotateHands())
+		}
+		events = append(events, s.nextTurn())
+	case SkipCard:
+		events = append(events, s.skipTurn()...)
+	case SkipEveryoneCard:
+		// Everyone else loses their turn, the player goes again
+		events = append(events, TurnStarted{Player: player.ID})
+	case ReverseCard:
+		s.Reversed = !s.Reversed
+		events = append(events, DirectionReversed{Reversed: s.Reversed})
+		if len(s.Players) == 2 {
+			// 2 Player reverse works as skip
+			events = append(events, s.skipTurn()...)
+		} else {
+			events = append(events, s.nextTurn())
+		}
+	case DrawOneCard, DrawTwoCard, DrawFiveCard:
+		if s.Rules.Stacking {
+			// Pass the penalty on, the next player can stack or draw
+			s.Penalty += card.DrawAmount()
+			events = append(events, s.nextTurn())
+			break
+		}
+		// Force the next player to draw and skip their turn.
+		events = append(events, s.give(s.NextPlayer(), card.DrawAmount()))
+		events = append(events, s.skipTurn()...)
+	case FlipCard:
+		events = append(events, s.flip())
+		// A wild turned face up gets its color from the player who flipped
+		if s.TopCard().IsWild() {
+			s.Phase = ColorPhase
+			break
+		}
+		events = append(events, s.nextTurn())
+	case WildCard:
+		s.Phase = ColorPhase
+	case WildDrawTwoCard, WildDrawFourCard, WildDrawColorCard:
+		s.Phase = ColorPhase
+		if s.Rules.Stacking && card.DrawAmount() > 0 {
+			// Passed on unless challenged, the next player can stack on it after ignoring the challenge
+			s.Penalty += card.DrawAmount()
+		}
+		s.Challenge = &PendingChallenge{
+			Player:        player.ID,
+			PreviousColor: previousColor,
+		}
+	}
+
+	return events, nil
+}
+
+// Draw one card from the deck
+func (s *State) drawCard(a DrawCard) ([]Event, error) {
+	player := s.CurrentPlayer()
+	if player.ID != a.Player {
+		return nil, ErrNotYourTurn
+	}
+	if s.Phase != TurnPhase {
+		return nil, ErrWrongPhase
+	}
+
+	// Couldn't or didn't stack, take the whole penalty and lose the turn
+	if s.Penalty > 0 {
+		events := []Event{s.give(player, s.Penalty)}
+		s.Penalty = 0
+		return append(events, s.nextTurn()), nil
+	}
+
+	drawn := s.give(player, 1).(CardsDrawn)
+	events := []Event{drawn}
+
+	// Nothing left to draw, move on
+	if len(drawn.Cards) == 0 {
+		return append(events, s.nextTurn()), nil
+	}
+
+	s.Drawn = &drawn.Cards[0]
+	s.Phase = KeepPhase
+	return events, nil
+}
+
+// Keep the drawn card and end the turn
+func (s *State) keepCard(a KeepCard) ([]Event, error) {
+	player := s.CurrentPlayer()
+	if player.ID != a.Player {
+		return nil, ErrNotYourTurn
+	}
+	if s.Phase != KeepPhase {
+		return nil, ErrWrongPhase
+	}
+
+	s.Drawn = nil
+	s.Phase = TurnPhase
+	return []Event{CardKept{Player: player.ID}, s.nextTurn()}, nil
+}
+
+// Change the current color for Wild and WildDrawFour cards
+func (s *State) chooseColor(a ChooseColor) ([]Event, error) {
+	player := s.CurrentPlayer()
+	if player.ID != a.Player {
+		return nil, ErrNotYourTurn
+	}
+	if s.Phase != ColorPhase {
+		return nil, ErrWrongPhase
+	}
+
+	valid := false
+	for _, color := range s.ActiveColors() {
+		if color == a.Color {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidColor
+	}
+
+	s.Color = a.Color
+	events := []Event{ColorChosen{Player: player.ID, Color: a.Color}}
+
+	// Wild draw cards wait for the next player to challenge
+	if s.Challenge != nil {
+		s.Phase = ChallengePhase
+		return events, nil
+	}
+
+	s.Phase = TurnPhase
+	return append(events, s.nextTurn()), nil
+}
+
+// Resolve a wild draw card, challenged or not
+func (s *State) challenge(a Challenge) ([]Event, error) {
+	if s.Phase != ChallengePhase {
+		return nil, ErrWrongPhase
+	}
+	challenger := s.NextPlayer()
+	if challenger.ID != a.Player {
+		return nil, ErrNotYourTurn
+	}
+
+	challenged := s.Player(s.Challenge.Player)
+	previousColor := s.Challenge.PreviousColor
+	s.Challenge = nil
+	s.Phase = TurnPhase
+
+	if !a.Challenge {
+		// Stacking, the challenger stacks on the penalty or draws it on their turn
+		if s.Penalty > 0 {
+			return []Event{s.nextTurn()}, nil
+		}
+		events := s.drawPenalty(challenger, 0)
+		return append(events, s.skipTurn()...), nil
+	}
+
+	// Wild draw cards are only legal without a card of the previous color
+	if challenged.hasColor(previousColor) {
+		events := []Event{ChallengeResolved{Challenger: challenger.ID, Challenged: challenged.ID, Won: true}}
+		events = append(events, s.drawPenalty(challenged, 0)...)
+		return append(events, s.nextTurn()), nil
+	}
+
+	// The challenger loses the challenge, draws two extra and their turn is skipped
+	events := []Event{ChallengeResolved{Challenger: challenger.ID, Challenged: challenged.ID, Won: false}}
+	events = append(events, s.drawPenalty(challenger, 2)...)
+	return append(events, s.skipTurn()...), nil
+}
+
+// Swap hands with the chosen player and end the turn
+func (s *State) swapHands(a SwapHands) ([]Event, error) {
+	player := s.CurrentPlayer()
+	if player.ID != a.Player {
+		return nil, ErrNotYourTurn
+	}
+	if s.Phase != SwapPhase {
+		return nil, ErrWrongPhase
+	}
+
+	target := s.Player(a.Target)
+	if target == nil || target == player {
+		return nil, ErrInvalidTarget
+	}
+
+	player.Hand, target.Hand = target.Hand, player.Hand
+	s.Phase = TurnPhase
+	return []Event{HandsSwapped{Player: player.ID, Target: target.ID}, s.nextTurn()}, nil
+}
+
+// Call UNO before someone catches you
+func (s *State) callUno(a CallUno) ([]Event, error) {
+	if s.UnoPending != a.Player {
+		return nil, ErrNoUno
+	}
+
+	s.UnoPending = ""
+	return []Event{UnoCalled{Player: a.Player}}, nil
+}
+
+// Catch a player who forgot to call UNO, they draw two cards
+func (s *State) catchUno(a CatchUno) ([]Event, error) {
+	if s.Uncalled == "" || s.Uncalled != a.Target || a.Player == a.Target {
+		return nil, ErrNoUno
+	}
+
+	s.Uncalled = ""
+	target := s.Player(a.Target)
+	return []Event{
+		UnoCaught{Player: a.Player, Target: target.ID},
+		s.give(target, 2),
+	}, nil
+}
+
+// Close the window to call UNO, from now on the player can only be caught
+func (s *State) expireUno(a ExpireUno) ([]Event, error) {
+	if s.UnoPending != a.Player {
+		return nil, ErrNoUno
+	}
+
+	s.UnoPending = ""
+	s.Uncalled = a.Player
+	return []Event{UnoMissed{Player: a.Player}}, nil
+}
@@ -0,0 +1,473 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWildDrawFourChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		kept      Card // Card the Wild Draw Four player keeps, red was the color before it
+		challenge bool
+		drawer    string // Player who draws
+		drawn     int
+		want      string // Current player afterwards
+		resolved  bool   // ChallengeResolved event
+		won       bool
+	}{
+		{"accepted", testCard("k", NumberCard, "blue", "3"), false, "p1", 4, "p2", false, false},
+		{"challenge won", testCard("k", NumberCard, "red", "9"), true, "p0", 4, "p1", true, true},
+		{"challenge lost", testCard("k", NumberCard, "blue", "3"), true, "p1", 6, "p2", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testState(Rules{},
+				[]Card{testCard("wd4", WildDrawFourCard, "", ""), tt.kept},
+				[]Card{testCard("c", NumberCard, "blue", "1"), testCard("d", NumberCard, "blue", "2")},
+				[]Card{testCard("e", NumberCard, "blue", "4"), testCard("f", NumberCard, "blue", "5")},
+			)
+			if _, err := s.Apply(PlayCard{Player: "p0", CardID: "wd4"}); err != nil {
+				t.Fatalf("play: %v", err)
+			}
+			if _, err := s.Apply(ChooseColor{Player: "p0", Color: "green"}); err != nil {
+				t.Fatalf("color: %v", err)
+			}
+			if s.Phase != ChallengePhase {
+				t.Fatalf("phase = %v, want ChallengePhase", s.Phase)
+			}
+			if _, err := s.Apply(Challenge{Player: "p2", Challenge: tt.challenge}); err != ErrNotYourTurn {
+				t.Errorf("challenge by the wrong player: err = %v, want %v", err, ErrNotYourTurn)
+			}
+
+			before := len(s.Player(tt.drawer).Hand)
+			events, err := s.Apply(Challenge{Player: "p1", Challenge: tt.challenge})
+			if err != nil {
+				t.Fatalf("challenge: %v", err)
+			}
+
+			if got := len(s.Player(tt.drawer).Hand) - before; got != tt.drawn {
+				t.Errorf("%s drew %d cards, want %d", tt.drawer, got, tt.drawn)
+			}
+			if got := s.CurrentPlayer().ID; got != tt.want {
+				t.Errorf("current player = %s, want %s", got, tt.want)
+			}
+			if s.Phase != TurnPhase || s.Challenge != nil {
+				t.Errorf("phase = %v, challenge = %v, want TurnPhase without a challenge", s.Phase, s.Challenge)
+			}
+
+			resolved := false
+			for _, event := range events {
+				if e, ok := event.(ChallengeResolved); ok {
+					resolved = true
+					if e.Won != tt.won {
+						t.Errorf("challenge won = %v, want %v", e.Won, tt.won)
+					}
+				}
+			}
+			if resolved != tt.resolved {
+				t.Errorf("challenge resolved = %v, want %v", resolved, tt.resolved)
+			}
+		})
+	}
+}
+
+func TestStackedWildDrawFourChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		kept      Card // Card the Wild Draw Four player keeps, red was the color before it
+		challenge bool
+		drawer    string // Player who draws right away, empty when p1 still has to stack or draw
+		drawn     int
+		want      string // Current player afterwards
+		penalty   int    // Penalty left for the current player
+	}{
+		{"ignored", testCard("k", NumberCard, "blue", "3"), false, "", 0, "p1", 4},
+		{"challenge won", testCard("k", NumberCard, "red", "9"), true, "p0", 4, "p1", 0},
+		{"challenge lost", testCard("k", NumberCard, "blue", "3"), true, "p1", 6, "p2", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testState(Rules{Stacking: true},
+				[]Card{testCard("wd4", WildDrawFourCard, "", ""), tt.kept},
+				[]Card{testCard("c", WildDrawFourCard, "", ""), testCard("d", NumberCard, "blue", "2")},
+				[]Card{testCard("e", NumberCard, "blue", "4"), testCard("f", NumberCard, "blue", "5")},
+			)
+			if _, err := s.Apply(PlayCard{Player: "p0", CardID: "wd4"}); err != nil {
+				t.Fatalf("play: %v", err)
+			}
+			if _, err := s.Apply(ChooseColor{Player: "p0", Color: "green"}); err != nil {
+				t.Fatalf("color: %v", err)
+			}
+			if s.Phase != ChallengePhase || s.Penalty != 4 {
+				t.Fatalf("phase = %v, penalty = %d, want ChallengePhase with a penalty of 4", s.Phase, s.Penalty)
+			}
+
+			var before int
+			if tt.drawer != "" {
+				before = len(s.Player(tt.drawer).Hand)
+			}
+			if _, err := s.Apply(Challenge{Player: "p1", Challenge: tt.challenge}); err != nil {
+				t.Fatalf("challenge: %v", err)
+			}
+
+			if tt.drawer != "" {
+				if got := len(s.Player(tt.drawer).Hand) - before; got != tt.drawn {
+					t.Errorf("%s drew %d cards, want %d", tt.drawer, got, tt.drawn)
+				}
+			}
+			if got := s.CurrentPlayer().ID; got != tt.want {
+				t.Errorf("current player = %s, want %s", got, tt.want)
+			}
+			if s.Phase != TurnPhase || s.Penalty != tt.penalty {
+				t.Errorf("phase = %v, penalty = %d, want TurnPhase with a penalty of %d", s.Phase, s.Penalty, tt.penalty)
+			}
+		})
+	}
+}
+
+func TestStackOnIgnoredChallenge(t *testing.T) {
+	s := testState(Rules{Stacking: true},
+		[]Card{testCard("wd4", WildDrawFourCard, "", ""), testCard("k", NumberCard, "blue", "3")},
+		[]Card{testCard("c", WildDrawFourCard, "", ""), testCard("d", NumberCard, "blue", "2")},
+		[]Card{testCard("e", NumberCard, "blue", "4"), testCard("f", NumberCard, "blue", "5")},
+	)
+	for _, action := range []Action{
+		PlayCard{Player: "p0", CardID: "wd4"},
+		ChooseColor{Player: "p0", Color: "green"},
+		Challenge{Player: "p1", Challenge: false},
+		PlayCard{Player: "p1", CardID: "c"},
+		ChooseColor{Player: "p1", Color: "blue"},
+		Challenge{Player: "p2", Challenge: false},
+	} {
+		if _, err := s.Apply(action); err != nil {
+			t.Fatalf("%T: %v", action, err)
+		}
+	}
+	if s.Penalty != 8 {
+		t.Fatalf("penalty = %d, want 8", s.Penalty)
+	}
+
+	before := len(s.Player("p2").Hand)
+	if _, err := s.Apply(DrawCard{Player: "p2"}); err != nil {
+		t.Fatalf("draw: %v", err)
+	}
+	if got := len(s.Player("p2").Hand) - before; got != 8 {
+		t.Errorf("p2 drew %d cards, want 8", got)
+	}
+	if s.Penalty != 0 {
+		t.Errorf("penalty = %d after drawing, want 0", s.Penalty)
+	}
+}
+
+func TestJumpInTakesTheTurn(t *testing.T) {
+	s := testState(Rules{JumpIn: true},
+		[]Card{testCard("a", NumberCard, "blue", "1"), testCard("b", NumberCard, "blue", "2")},
+		[]Card{testCard("c", NumberCard, "blue", "3"), testCard("d", NumberCard, "blue", "4")},
+		[]Card{testCard("e", NumberCard, "red", "5"), testCard("f", NumberCard, "blue", "6")},
+	)
+
+	if _, err := s.Apply(PlayCard{Player: "p1", CardID: "c"}); err != ErrNotYourTurn {
+		t.Fatalf("playing a different card out of turn: err = %v, want %v", err, ErrNotYourTurn)
+	}
+
+	events, err := s.Apply(PlayCard{Player: "p2", CardID: "e"})
+	if err != nil {
+		t.Fatalf("jump-in: %v", err)
+	}
+	if jumped, ok := events[0].(JumpedIn); !ok || jumped.Player != "p2" {
+		t.Errorf("first event = %#v, want JumpedIn by p2", events[0])
+	}
+	// Play continues from the jumper
+	if got := s.CurrentPlayer().ID; got != "p0" {
+		t.Errorf("current player = %s, want p0", got)
+	}
+}
+
+func TestFailedJumpInLeavesTheTurn(t *testing.T) {
+	s := testState(Rules{JumpIn: true},
+		[]Card{testCard("a", NumberCard, "blue", "1"), testCard("b", NumberCard, "blue", "2")},
+		[]Card{testCard("c", NumberCard, "red", "5"), testCard("d", NumberCard, "blue", "4")},
+	)
+	s.Deck = []Card{testCard("drawn", NumberCard, "red", "7")}
+	if _, err := s.Apply(DrawCard{Player: "p0"}); err != nil {
+		t.Fatalf("draw: %v", err)
+	}
+
+	// p0 is deciding whether to keep the drawn card, nobody can jump in
+	if _, err := s.Apply(PlayCard{Player: "p1", CardID: "c"}); err == nil {
+		t.Fatal("jump-in while a drawn card is pending: want an error")
+	}
+	if got := s.CurrentPlayer().ID; got != "p0" {
+		t.Errorf("current player = %s, want p0", got)
+	}
+	if s.Phase != KeepPhase || len(s.Player("p1").Hand) != 2 {
+		t.Errorf("phase = %v, p1 has %d cards, want KeepPhase and 2 cards", s.Phase, len(s.Player("p1").Hand))
+	}
+}
+
+func TestSevenSwapsHands(t *testing.T) {
+	s := testState(Rules{SevenO: true},
+		[]Card{testCard("seven", NumberCard, "red", "7"), testCard("a", NumberCard, "blue", "1"), testCard("b", NumberCard, "blue", "2")},
+		[]Card{testCard("c", NumberCard, "blue", "3")},
+		[]Card{testCard("d", NumberCard, "blue", "4"), testCard("e", NumberCard, "blue", "5"), testCard("f", NumberCard, "blue", "6")},
+	)
+	if _, err := s.Apply(PlayCard{Player: "p0", CardID: "seven"}); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if s.Phase != SwapPhase {
+		t.Fatalf("phase = %v, want SwapPhase", s.Phase)
+	}
+	if _, err := s.Apply(SwapHands{Player: "p0", Target: "p0"}); err != ErrInvalidTarget {
+		t.Errorf("swap with yourself: err = %v, want %v", err, ErrInvalidTarget)
+	}
+	if _, err := s.Apply(SwapHands{Player: "p1", Target: "p2"}); err != ErrNotYourTurn {
+		t.Errorf("swap by the wrong player: err = %v, want %v", err, ErrNotYourTurn)
+	}
+
+	if _, err := s.Apply(SwapHands{Player: "p0", Target: "p2"}); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if got := cardIDs(s.Player("p0").Hand); len(got) != 3 || s.Player("p0").Hand[0].ID != "d" {
+		t.Errorf("p0 has %v, want the hand of p2", got)
+	}
+	if got := cardIDs(s.Player("p2").Hand); len(got) != 2 || s.Player("p2").Hand[0].ID != "a" {
+		t.Errorf("p2 has %v, want the hand of p0", got)
+	}
+	if s.Phase != TurnPhase || s.CurrentPlayer().ID != "p1" {
+		t.Errorf("phase = %v, current player = %s, want TurnPhase and p1", s.Phase, s.CurrentPlayer().ID)
+	}
+}
+
+func TestZeroRotatesHands(t *testing.T) {
+	tests := []struct {
+		name     string
+		reversed bool
+		want     []string // First card of every player afterwards
+	}{
+		{"clockwise", false, []string{"e", "a", "c"}},
+		{"reversed", true, []string{"c", "e", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testState(Rules{SevenO: true},
+				[]Card{testCard("a", NumberCard, "blue", "1"), testCard("zero", NumberCard, "red", "0"), testCard("b", NumberCard, "blue", "2")},
+				[]Card{testCard("c", NumberCard, "blue", "3"), testCard("d", NumberCard, "blue", "4")},
+				[]Card{testCard("e", NumberCard, "blue", "5"), testCard("f", NumberCard, "blue", "6")},
+			)
+			s.Reversed = tt.reversed
+
+			events, err := s.Apply(PlayCard{Player: "p0", CardID: "zero"})
+			if err != nil {
+				t.Fatalf("play: %v", err)
+			}
+			for index, player := range s.Players {
+				if got := player.Hand[0].ID; got != tt.want[index] {
+					t.Errorf("%s holds %s first, want %s", player.ID, got, tt.want[index])
+				}
+			}
+
+			rotated := false
+			for _, event := range events {
+				if e, ok := event.(HandsRotated); ok {
+					rotated = e.Reversed == tt.reversed
+				}
+			}
+			if !rotated {
+				t.Errorf("no HandsRotated event with reversed = %v", tt.reversed)
+			}
+		})
+	}
+}
+
+func TestCallUno(t *testing.T) {
+	s := testState(Rules{},
+		[]Card{testCard("a", NumberCard, "red", "1"), testCard("b", NumberCard, "blue", "2")},
+		[]Card{testCard("c", NumberCard, "blue", "3"), testCard("d", NumberCard, "blue", "4")},
+	)
+	if _, err := s.Apply(PlayCard{Player: "p0", CardID: "a"}); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if s.UnoPending != "p0" {
+		t.Fatalf("UNO pending for %q, want p0", s.UnoPending)
+	}
+
+	// Nobody can be caught while there's still time to call
+	if _, err := s.Apply(CatchUno{Player: "p1", Target: "p0"}); err != ErrNoUno {
+		t.Errorf("catch within the window: err = %v, want %v", err, ErrNoUno)
+	}
+	if _, err := s.Apply(CallUno{Player: "p1"}); err != ErrNoUno {
+		t.Errorf("call by a player with two cards: err = %v, want %v", err, ErrNoUno)
+	}
+
+	events, err := s.Apply(CallUno{Player: "p0"})
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if called, ok := events[0].(UnoCalled); !ok || called.Player != "p0" {
+		t.Errorf("event = %#v, want UnoCalled by p0", events[0])
+	}
+	if s.UnoPending != "" {
+		t.Errorf("UNO pending for %q after calling", s.UnoPending)
+	}
+	if _, err := s.Apply(ExpireUno{Player: "p0"}); err != ErrNoUno {
+		t.Errorf("window closing after the call: err = %v, want %v", err, ErrNoUno)
+	}
+}
+
+func TestCatchUno(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  []Action // What happens after the window closed on p0
+		catcher string
+		caught  bool
+	}{
+		{"caught", nil, "p1", true},
+		{"caught before the color is picked", []Action{ChooseColor{Player: "p0", Color: "blue"}}, "p1", true},
+		{"catching yourself", nil, "p0", false},
+		{"next player acted", []Action{ChooseColor{Player: "p0", Color: "blue"}, DrawCard{Player: "p1"}}, "p1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testState(Rules{},
+				[]Card{testCard("wild", WildCard, "", ""), testCard("b", NumberCard, "blue", "2")},
+				[]Card{testCard("c", NumberCard, "green", "3"), testCard("d", NumberCard, "green", "4")},
+			)
+			s.Deck = []Card{testCard("e", NumberCard, "green", "6"), testCard("f", NumberCard, "green", "7"), testCard("g", NumberCard, "green", "8")}
+			for _, action := range []Action{PlayCard{Player: "p0", CardID: "wild"}, ExpireUno{Player: "p0"}} {
+				if _, err := s.Apply(action); err != nil {
+					t.Fatalf("%T: %v", action, err)
+				}
+			}
+			if s.UnoPending != "" || s.Uncalled != "p0" {
+				t.Fatalf("pending = %q, uncalled = %q, want p0 to be catchable", s.UnoPending, s.Uncalled)
+			}
+			if _, err := s.Apply(CallUno{Player: "p0"}); err != ErrNoUno {
+				t.Errorf("call after the window: err = %v, want %v", err, ErrNoUno)
+			}
+			for _, action := range tt.before {
+				if _, err := s.Apply(action); err != nil {
+					t.Fatalf("%T: %v", action, err)
+				}
+			}
+
+			events, err := s.Apply(CatchUno{Player: tt.catcher, Target: "p0"})
+			if !tt.caught {
+				if err != ErrNoUno {
+					t.Errorf("catch: err = %v, want %v", err, ErrNoUno)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("catch: %v", err)
+			}
+			if caught, ok := events[0].(UnoCaught); !ok || caught.Player != tt.catcher {
+				t.Errorf("event = %#v, want UnoCaught by %s", events[0], tt.catcher)
+			}
+			if got := len(s.Player("p0").Hand); got != 3 {
+				t.Errorf("p0 has %d cards, want 3", got)
+			}
+			if s.Uncalled != "" {
+				t.Errorf("p0 can still be caught after drawing")
+			}
+		})
+	}
+}
+
+// Double-sided card for UNO Flip tests
+func testFlipCard(id string, light Face, dark Face) Card {
+	light.Name, dark.Name = id, id+"-dark"
+	return Card{ID: id, Face: light, Flipside: &dark}
+}
+
+func TestFlipCard(t *testing.T) {
+	tests := []struct {
+		name  string
+		top   Face // Dark side of the flip card
+		phase Phase
+		color string
+		want  string // Current player afterwards
+	}{
+		{"colored card", Face{Type: NumberCard, Color: "teal", Value: "3"}, TurnPhase, "teal", "p1"},
+		{"wild", Face{Type: WildCard}, ColorPhase, "", "p0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testState(Rules{Flip: true},
+				[]Card{
+					testFlipCard("flip", Face{Type: FlipCard, Color: "red", Value: "flip"}, tt.top),
+					testFlipCard("a", Face{Type: NumberCard, Color: "blue", Value: "1"}, Face{Type: NumberCard, Color: "pink", Value: "1"}),
+				},
+				[]Card{testFlipCard("b", Face{Type: NumberCard, Color: "blue", Value: "2"}, Face{Type: NumberCard, Color: "orange", Value: "2"})},
+			)
+			s.Deck = []Card{testFlipCard("d", Face{Type: NumberCard, Color: "green", Value: "4"}, Face{Type: NumberCard, Color: "purple", Value: "4"})}
+
+			events, err := s.Apply(PlayCard{Player: "p0", CardID: "flip"})
+			if err != nil {
+				t.Fatalf("play: %v", err)
+			}
+			if !s.Dark {
+				t.Fatal("light side still up")
+			}
+			if flipped, ok := events[1].(Flipped); !ok || !flipped.Dark {
+				t.Errorf("event = %#v, want Flipped to the dark side", events[1])
+			}
+			if got := s.TopCard().Name; got != "flip-dark" {
+				t.Errorf("top card = %s, want the dark side of the flip card", got)
+			}
+			if s.Player("p0").Hand[0].Color != "pink" || s.Player("p1").Hand[0].Color != "orange" || s.Deck[0].Color != "purple" {
+				t.Error("hands and deck didn't turn over")
+			}
+			if s.Phase != tt.phase || s.Color != tt.color || s.CurrentPlayer().ID != tt.want {
+				t.Errorf("phase = %v, color = %q, current player = %s, want %v, %q and %s",
+					s.Phase, s.Color, s.CurrentPlayer().ID, tt.phase, tt.color, tt.want)
+			}
+			if !slices.Equal(s.ActiveColors(), DarkColors) {
+				t.Errorf("active colors = %v, want the dark colors", s.ActiveColors())
+			}
+		})
+	}
+}
+
+func TestWildDrawColor(t *testing.T) {
+	s := testState(Rules{Flip: true},
+		[]Card{testCard("wdc", WildDrawColorCard, "", ""), testCard("k", NumberCard, "pink", "3")},
+		[]Card{testCard("b", NumberCard, "pink", "2")},
+		[]Card{testCard("c", NumberCard, "pink", "4")},
+	)
+	s.Dark = true
+	s.DiscardPile = []Card{testCard("top", NumberCard, "pink", "5")}
+	s.Color = "pink"
+	s.Deck = []Card{
+		testCard("d1", NumberCard, "pink", "1"),
+		testCard("d2", NumberCard, "orange", "1"),
+		testCard("d3", NumberCard, "teal", "6"),
+		testCard("d4", NumberCard, "pink", "7"),
+	}
+
+	for _, action := range []Action{
+		PlayCard{Player: "p0", CardID: "wdc"},
+		ChooseColor{Player: "p0", Color: "teal"},
+		Challenge{Player: "p1", Challenge: false},
+	} {
+		if _, err := s.Apply(action); err != nil {
+			t.Fatalf("%T: %v", action, err)
+		}
+	}
+
+	// Drawn from the top of the deck until teal shows up
+	if got := len(s.Player("p1").Hand); got != 4 {
+		t.Fatalf("p1 has %d cards, want 4", got)
+	}
+	if got := s.Player("p1").Hand[3].Color; got != "teal" {
+		t.Errorf("last card drawn is %s, want teal", got)
+	}
+	if got := s.CurrentPlayer().ID; got != "p2" {
+		t.Errorf("current player = %s, want p2", got)
+	}
+}
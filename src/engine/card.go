@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+type CardType int
+
+type Card struct {
+	ID string
+	Face
+	Flipside *Face // Other side of an UNO Flip card, nil for the standard deck
+}
+
+// What is printed on one side of a card
+type Face struct {
+	Name  string
+	Link  string
+	Type  CardType
+	Color string // Empty for wild cards
+	Value string // Number for number cards, the action otherwise
+}
+
+const (
+	NumberCard CardType = iota
+	SkipCard
+	ReverseCard
+	DrawTwoCard
+	WildCard
+	WildDrawFourCard
+	// UNO Flip light side
+	DrawOneCard
+	FlipCard
+	WildDrawTwoCard
+	// UNO Flip dark side
+	DrawFiveCard
+	SkipEveryoneCard
+	WildDrawColorCard
+	CardBack
+)
+
+// Colors of the UNO Flip dark side
+var DarkColors = []string{"pink", "teal", "orange", "purple"}
+
+func GenerateDeck() []Card {
+	return DefaultDeck.Generate()
+}
+
+// Light and dark faces of the UNO Flip deck, every color gets 1-9, two of each action and Flip.
+// The dark faces are shuffled onto the backs of the light ones.
+func GenerateFlipDeck(rng *rand.Rand) []Card {
+	light := flipFaces(Colors, DrawOneCard, SkipCard, WildDrawTwoCard)
+	dark := flipFaces(DarkColors, DrawFiveCard, SkipEveryoneCard, WildDrawColorCard)
+	rng.Shuffle(len(dark), func(i, j int) {
+		dark[i], dark[j] = dark[j], dark[i]
+	})
+
+	deck := make([]Card, len(light))
+	for i, face := range light {
+		back := dark[i]
+		deck[i] = Card{ID: cardID(i), Face: face, Flipside: &back}
+	}
+	return deck
+}
+
+// Faces for one side of the UNO Flip deck
+func flipFaces(colors []string, draw CardType, skip CardType, wildDraw CardType) []Face {
+	var faces []Face
+	for _, color := range colors {
+		for copy := 0; copy < 2; copy++ {
+			for value := 1; value <= 9; value++ {
+				faces = append(faces, Face{Name: color + "-" + strconv.Itoa(value), Type: NumberCard, Color: color, Value: strconv.Itoa(value)})
+			}
+			for _, action := range []CardType{draw, ReverseCard, skip, FlipCard} {
+				faces = append(faces, Face{Name: color + "-" + faceNames[action], Type: action, Color: color, Value: faceNames[action]})
+			}
+		}
+	}
+	for copy := 0; copy < 4; copy++ {
+		for _, wild := range []CardType{WildCard, wildDraw} {
+			faces = append(faces, Face{Name: "wild-" + faceNames[wild], Type: wild, Value: faceNames[wild]})
+		}
+	}
+	return faces
+}
+
+// Value part of the name for UNO Flip action cards
+var faceNames = map[CardType]string{
+	DrawOneCard:       "drawone",
+	SkipCard:          "skip",
+	ReverseCard:       "reverse",
+	FlipCard:          "flip",
+	WildCard:          "color",
+	WildDrawTwoCard:   "drawtwo",
+	DrawFiveCard:      "drawfive",
+	SkipEveryoneCard:  "skipall",
+	WildDrawColorCard: "drawcolor",
+}
+
+// Cards are numbered in deck order before shuffling, so the same seed always deals the same IDs
+func cardID(index int) string {
+	return "c" + strconv.Itoa(index)
+}
+
+// Turn the card over to its other side
+func (c *Card) Flip() {
+	if c.Flipside == nil {
+		return
+	}
+	front := c.Face
+	c.Face = *c.Flipside
+	c.Flipside = &front
+}
+
+// Shuffle deck of cards
+func ShuffleDeck(deck []Card, rng *rand.Rand) {
+	rng.Shuffle(len(deck), func(i, j int) {
+		(deck)[i], (deck)[j] = (deck)[j], (deck)[i]
+	})
+}
+
+// Check if the card can be played on the top card while color is active
+func (c Card) Matches(top Card, color string) bool {
+	if c.IsWild() || c.Color == color {
+		return true
+	}
+
+	// Same symbol, numbers also have to match value
+	return c.Type == top.Type && (c.Type != NumberCard || c.Value == top.Value)
+}
+
+// Points the card is worth to the round winner when left in a hand
+func (c Card) Points() int {
+	switch c.Type {
+	case NumberCard:
+		points, _ := strconv.Atoi(c.Value)
+		return points
+	case DrawOneCard:
+		return 10
+	case SkipCard, ReverseCard, DrawTwoCard, FlipCard, DrawFiveCard:
+		return 20
+	case SkipEveryoneCard:
+		return 30
+	case WildCard, WildDrawFourCard, WildDrawTwoCard:
+		return 50
+	case WildDrawColorCard:
+		return 60
+	}
+	return 0
+}
+
+// Check if the card can be played on any color
+func (c Card) IsWild() bool {
+	switch c.Type {
+	case WildCard, WildDrawFourCard, WildDrawTwoCard, WildDrawColorCard:
+		return true
+	}
+	return false
+}
+
+// Cards the next player draws when this card is played, 0 for cards without a fixed penalty
+func (c Card) DrawAmount() int {
+	switch c.Type {
+	case DrawOneCard:
+		return 1
+	case DrawTwoCard, WildDrawTwoCard:
+		return 2
+	case WildDrawFourCard:
+		return 4
+	case DrawFiveCard:
+		return 5
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestPoints(t *testing.T) {
+	tests := []struct {
+		card Card
+		want int
+	}{
+		{testCard("zero", NumberCard, "red", "0"), 0},
+		{testCard("seven", NumberCard, "red", "7"), 7},
+		{testCard("draw one", DrawOneCard, "red", ""), 10},
+		{testCard("skip", SkipCard, "red", ""), 20},
+		{testCard("reverse", ReverseCard, "red", ""), 20},
+		{testCard("draw two", DrawTwoCard, "red", ""), 20},
+		{testCard("flip", FlipCard, "red", ""), 20},
+		{testCard("draw five", DrawFiveCard, "pink", ""), 20},
+		{testCard("skip everyone", SkipEveryoneCard, "pink", ""), 30},
+		{testCard("wild", WildCard, "", ""), 50},
+		{testCard("wild draw four", WildDrawFourCard, "", ""), 50},
+		{testCard("wild draw two", WildDrawTwoCard, "", ""), 50},
+		{testCard("wild draw color", WildDrawColorCard, "", ""), 60},
+	}
+
+	for _, tt := range tests {
+		if got := tt.card.Points(); got != tt.want {
+			t.Errorf("%s: %d points, want %d", tt.card.Name, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateFlipDeck(t *testing.T) {
+	deck := GenerateFlipDeck(rand.New(rand.NewSource(1)))
+	if len(deck) != 112 {
+		t.Fatalf("%d cards, want 112", len(deck))
+	}
+
+	dark := map[string]int{}
+	for _, card := range deck {
+		if card.Flipside == nil {
+			t.Fatalf("%s has no dark side", card.Name)
+		}
+		if card.Color != "" && !slices.Contains(Colors, card.Color) {
+			t.Errorf("light side %s has a dark color", card.Name)
+		}
+		if card.Flipside.Color != "" && !slices.Contains(DarkColors, card.Flipside.Color) {
+			t.Errorf("dark side %s has a light color", card.Flipside.Name)
+		}
+		dark[card.Flipside.Name]++
+	}
+	// Every dark face is on the back of some light card
+	if dark["wild-drawcolor"] != 4 || dark["pink-skipall"] != 2 || dark["teal-9"] != 2 {
+		t.Errorf("dark faces %d wild-drawcolor, %d pink-skipall, %d teal-9, want 4, 2 and 2",
+			dark["wild-drawcolor"], dark["pink-skipall"], dark["teal-9"])
+	}
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Ranzz02/uno-discord-bot/assets"
+)
+
+// Deck definition, the card catalogue and how many copies of each card go in the deck
+type Deck struct {
+	Back  string     `json:"back"` // Image of the back of a card
+	Cards []DeckCard `json:"cards"`
+}
+
+// Card in a deck definition
+type DeckCard struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Color  string `json:"color"` // Empty for wild cards
+	Value  string `json:"value"` // Number for number cards
+	Copies int    `json:"copies"`
+	Link   string `json:"link"`
+}
+
+// Card types that can be used in a deck definition
+var CardTypes = map[string]CardType{
+	"number":          NumberCard,
+	"skip":            SkipCard,
+	"reverse":         ReverseCard,
+	"draw-two":        DrawTwoCard,
+	"wild":            WildCard,
+	"wild-draw-four":  WildDrawFourCard,
+	"draw-one":        DrawOneCard,
+	"wild-draw-two":   WildDrawTwoCard,
+	"draw-five":       DrawFiveCard,
+	"skip-everyone":   SkipEveryoneCard,
+	"wild-draw-color": WildDrawColorCard,
+}
+
+var (
+	ErrEmptyDeck     = errors.New("deck has no cards")
+	ErrNoNumberCards = errors.New("deck needs at least one number card to start on")
+)
+
+// Deck used by servers without a custom deck, loaded from assets/cards.json
+var DefaultDeck *Deck
+
+func init() {
+	deck, err := ParseDeck(assets.Cards)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded deck: %v", err))
+	}
+	DefaultDeck = deck
+}
+
+// Parse and validate a deck definition
+func ParseDeck(data []byte) (*Deck, error) {
+	deck := &Deck{}
+	if err := json.Unmarshal(data, deck); err != nil {
+		return nil, err
+	}
+	if err := deck.Validate(); err != nil {
+		return nil, err
+	}
+	return deck, nil
+}
+
+// Check every card is well formed and the deck can be played with
+func (d *Deck) Validate() error {
+	total := 0
+	numbers := 0
+	names := map[string]bool{}
+
+	for index, card := range d.Cards {
+		cardType, ok := CardTypes[card.Type]
+		if !ok {
+			return fmt.Errorf("card %d (%q): unknown type %q", index+1, card.Name, card.Type)
+		}
+		if card.Name == "" {
+			return fmt.Errorf("card %d: missing name", index+1)
+		}
+		if names[card.Name] {
+			return fmt.Errorf("card %d (%q): defined twice", index+1, card.Name)
+		}
+		names[card.Name] = true
+		if card.Copies < 0 {
+			return fmt.Errorf("card %d (%q): copies can't be negative", index+1, card.Name)
+		}
+
+		wild := Card{Face: Face{Type: cardType}}.IsWild()
+		if wild && card.Color != "" {
+			return fmt.Errorf("card %d (%q): wild cards can't have a color", index+1, card.Name)
+		}
+		if !wild && !contains(Colors, card.Color) {
+			return fmt.Errorf("card %d (%q): color must be one of %s", index+1, card.Name, strings.Join(Colors, ", "))
+		}
+		if _, err := strconv.Atoi(card.Value); cardType == NumberCard && err != nil {
+			return fmt.Errorf("card %d (%q): number cards need a number value", index+1, card.Name)
+		}
+
+		total += card.Copies
+		if cardType == NumberCard {
+			numbers += card.Copies
+		}
+	}
+
+	if total == 0 {
+		return ErrEmptyDeck
+	}
+	if numbers == 0 {
+		return ErrNoNumberCards
+	}
+	return nil
+}
+
+// Number of cards the deck is generated with
+func (d *Deck) Size() int {
+	total := 0
+	for _, card := range d.Cards {
+		total += card.Copies
+	}
+	return total
+}
+
+// Create every copy of every card in definition order
+func (d *Deck) Generate() []Card {
+	var deck []Card
+	for _, definition := range d.Cards {
+		for copy := 0; copy < definition.Copies; copy++ {
+			deck = append(deck, Card{
+				ID: cardID(len(deck)),
+				Face: Face{
+					Name:  definition.Name,
+					Link:  definition.Link,
+					Type:  CardTypes[definition.Type],
+					Color: definition.Color,
+					Value: definition.Value,
+				},
+			})
+		}
+	}
+	return deck
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	red := DeckCard{Name: "red-5", Type: "number", Color: "red", Value: "5", Copies: 2}
+	wild := DeckCard{Name: "wild", Type: "wild", Copies: 4}
+
+	tests := []struct {
+		name  string
+		cards []DeckCard
+		want  string // Part of the error, empty when the deck is valid
+	}{
+		{"valid", []DeckCard{red, wild}, ""},
+		{"unknown type", []DeckCard{red, {Name: "x", Type: "draw-ten", Color: "red", Copies: 1}}, `unknown type "draw-ten"`},
+		{"missing name", []DeckCard{red, {Type: "skip", Color: "red", Copies: 1}}, "card 2: missing name"},
+		{"missing names aren't duplicates", []DeckCard{red, {Type: "skip", Color: "red", Copies: 1}, {Type: "skip", Color: "blue", Copies: 1}}, "card 2: missing name"},
+		{"duplicate", []DeckCard{red, wild, red}, "defined twice"},
+		{"negative copies", []DeckCard{red, {Name: "skip", Type: "skip", Color: "red", Copies: -1}}, "negative"},
+		{"colored wild", []DeckCard{red, {Name: "wild", Type: "wild", Color: "red", Copies: 1}}, "can't have a color"},
+		{"unknown color", []DeckCard{red, {Name: "skip", Type: "skip", Color: "pink", Copies: 1}}, "color must be one of"},
+		{"number without a number", []DeckCard{{Name: "red-x", Type: "number", Color: "red", Value: "x", Copies: 1}}, "need a number value"},
+		{"no cards", []DeckCard{{Name: "red-5", Type: "number", Color: "red", Value: "5"}}, ErrEmptyDeck.Error()},
+		{"no number cards", []DeckCard{wild}, ErrNoNumberCards.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Deck{Cards: tt.cards}).Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error with %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDeck(t *testing.T) {
+	deck, err := ParseDeck([]byte(`{"back": "back.png", "cards": [{"name": "blue-1", "type": "number", "color": "blue", "value": "1", "copies": 3}]}`))
+	if err != nil {
+		t.Fatalf("ParseDeck: %v", err)
+	}
+	if deck.Size() != 3 || len(deck.Generate()) != 3 {
+		t.Errorf("size %d, generated %d cards, want 3", deck.Size(), len(deck.Generate()))
+	}
+
+	if _, err := ParseDeck([]byte(`{"cards": []}`)); err != ErrEmptyDeck {
+		t.Errorf("empty deck: err = %v, want %v", err, ErrEmptyDeck)
+	}
+	if _, err := ParseDeck([]byte(`not json`)); err == nil {
+		t.Error("broken JSON: want an error")
+	}
+	if DefaultDeck.Size() != 108 {
+		t.Errorf("default deck has %d cards, want 108", DefaultDeck.Size())
+	}
+}
@@ -0,0 +1,116 @@
+package engine
+
+// Event describes something that happened while applying an action
+type Event interface {
+	event()
+}
+
+// Player played a card on the discard pile
+type CardPlayed struct {
+	Player string
+	Card   Card
+}
+
+// Player played an identical card out of turn
+type JumpedIn struct {
+	Player string
+}
+
+// Player drew cards from the deck
+type CardsDrawn struct {
+	Player string
+	Cards  []Card
+}
+
+// Player kept the card they drew
+type CardKept struct {
+	Player string
+}
+
+// Player picked the color of a wild card
+type ColorChosen struct {
+	Player string
+	Color  string
+}
+
+// Wild Draw Four was challenged, Won is true if the challenger was right
+type ChallengeResolved struct {
+	Challenger string
+	Challenged string
+	Won        bool
+}
+
+// Player lost their turn
+type TurnSkipped struct {
+	Player string
+}
+
+// Direction of play changed
+type DirectionReversed struct {
+	Reversed bool
+}
+
+// Every card was turned over by an UNO Flip card
+type Flipped struct {
+	Dark bool
+}
+
+// Player swapped hands with the target after a 7
+type HandsSwapped struct {
+	Player string
+	Target string
+}
+
+// Every hand moved one seat along after a 0
+type HandsRotated struct {
+	Reversed bool
+}
+
+// Player called UNO in time
+type UnoCalled struct {
+	Player string
+}
+
+// Player didn't call UNO in time and can be caught
+type UnoMissed struct {
+	Player string
+}
+
+// Player caught the target not calling UNO
+type UnoCaught struct {
+	Player string
+	Target string
+}
+
+// Player left the game, their cards went back in the deck
+type PlayerLeft struct {
+	Player string
+}
+
+// Player is now up
+type TurnStarted struct {
+	Player string
+}
+
+// Player emptied their hand
+type GameWon struct {
+	Player string
+}
+
+func (CardPlayed) event()        {}
+func (JumpedIn) event()          {}
+func (CardsDrawn) event()        {}
+func (CardKept) event()          {}
+func (ColorChosen) event()       {}
+func (ChallengeResolved) event() {}
+func (TurnSkipped) event()       {}
+func (DirectionReversed) event() {}
+func (Flipped) event()           {}
+func (HandsSwapped) event()      {}
+func (HandsRotated) event()      {}
+func (UnoCalled) event()         {}
+func (UnoMissed) event()         {}
+func (UnoCaught) event()         {}
+func (PlayerLeft) event()        {}
+func (TurnStarted) event()       {}
+func (GameWon) event()           {}
@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Deck ID of the UNO Flip deck, it isn't built from a definition
+const FLIP_DECK_ID string = "flip"
+
+var (
+	ErrInvalidProof = errors.New("invalid proof")
+	ErrUnknownDeck  = errors.New("the proof was dealt from a deck this server doesn't have")
+)
+
+// Commit-reveal shuffle. The server seed shuffles a base deck order and the commitment to both
+// is shown before anyone joins. Players mix in their own seeds, the final seed shuffles the base
+// order again and the server seed is revealed when the game ends.
+type Fairness struct {
+	ServerSeed  string   // Secret until the game ends
+	DeckID      string   // Deck the base order was shuffled from
+	DeckHash    string   // Hash of the base deck order, secret until the game ends
+	Commitment  string   // Hash of the server seed and the base deck order, public from the start
+	PlayerSeeds []string // Hashes of the seeds players typed in the lobby
+}
+
+// Result of checking a proof
+type Verification struct {
+	Seed       int64
+	Commitment string // Recomputed from the revealed server seed and the deck
+	DeckHash   string // Hash of the recomputed first deck
+	Committed  bool   // Recomputed commitment matches the one published at game start
+}
+
+// Pick a server seed with crypto randomness and commit to it and the deck
+func NewFairness(rules Rules, deck *Deck) (*Fairness, error) {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	f := &Fairness{ServerSeed: hex.EncodeToString(seed)}
+	f.Commit(rules, deck)
+	return f, nil
+}
+
+// Commit to the base deck order again, for when the lobby changed the deck
+func (f *Fairness) Commit(rules Rules, deck *Deck) {
+	f.DeckID = DeckID(rules, deck)
+	f.DeckHash = HashDeck(f.Base(rules, deck))
+	f.Commitment = hash(f.ServerSeed + ":" + f.DeckHash)
+}
+
+// Mix a player's seed into the shuffle, it's hashed so any text works
+func (f *Fairness) Mix(seed string) string {
+	mixed := hash(seed)[:16]
+	f.PlayerSeeds = append(f.PlayerSeeds, mixed)
+	return mixed
+}
+
+// Seed of the base deck order, from the server seed alone
+func (f *Fairness) BaseSeed() int64 {
+	return seedOf(f.ServerSeed)
+}
+
+// Base deck order the commitment was made to
+func (f *Fairness) Base(rules Rules, deck *Deck) []Card {
+	return BaseDeck(rules, deck, f.BaseSeed())
+}
+
+// Shuffle seed from the server seed and every player seed, small enough to pass to /uno start seed
+func (f *Fairness) Seed() int64 {
+	return seedOf(f.ServerSeed + ":" + strings.Join(f.PlayerSeeds, ":"))
+}
+
+// Everything needed to recompute the commitment and the first deck
+func (f *Fairness) Proof() string {
+	return strings.Join([]string{f.DeckID, f.ServerSeed, strings.Join(f.PlayerSeeds, ",")}, ".")
+}
+
+// Recompute the deck order from a proof and check it against the commitment shown at game start.
+// The deck in the proof has to be one of decks, or the UNO Flip deck.
+func Verify(proof string, commitment string, decks ...*Deck) (*Verification, error) {
+	parts := strings.Split(strings.TrimSpace(proof), ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return nil, ErrInvalidProof
+	}
+
+	rules := Rules{Flip: parts[0] == FLIP_DECK_ID}
+	var deck *Deck
+	if !rules.Flip {
+		for _, candidate := range decks {
+			if candidate != nil && candidate.ID() == parts[0] {
+				deck = candidate
+				break
+			}
+		}
+		if deck == nil {
+			return nil, ErrUnknownDeck
+		}
+	}
+
+	f := &Fairness{ServerSeed: parts[1]}
+	if parts[2] != "" {
+		f.PlayerSeeds = strings.Split(parts[2], ",")
+	}
+	f.Commit(rules, deck)
+
+	// First round the way the game dealt it
+	seed := f.Seed()
+	s := NewWithDeck(rules, deck, seed)
+	s.Reseed(seed)
+	s.shuffleIn(f.Base(rules, deck))
+	return &Verification{
+		Seed:       seed,
+		Commitment: f.Commitment,
+		DeckHash:   s.DeckHash,
+		Committed:  f.Commitment == strings.TrimSpace(commitment),
+	}, nil
+}
+
+// Name of the deck in proofs, the UNO Flip deck or a hash of the definition
+func DeckID(rules Rules, deck *Deck) string {
+	if rules.Flip {
+		return FLIP_DECK_ID
+	}
+	if deck == nil {
+		deck = DefaultDeck
+	}
+	return deck.ID()
+}
+
+// Hash of the card order
+func HashDeck(cards []Card) string {
+	var order []string
+	for _, card := range cards {
+		side := card.ID + ":" + card.Name
+		if card.Flipside != nil {
+			side += "/" + card.Flipside.Name
+		}
+		order = append(order, side)
+	}
+	return hash(strings.Join(order, ","))
+}
+
+// Short hash of a deck definition
+func (d *Deck) ID() string {
+	data, _ := json.Marshal(d)
+	return hash(string(data))[:16]
+}
+
+func seedOf(value string) int64 {
+	sum := sha256.Sum256([]byte(value))
+	return int64(binary.BigEndian.Uint64(sum[:8]) >> 11)
+}
+
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+// Deal the first round of a fair game the way the bot does
+func testFairDeal(rules Rules, deck *Deck, f *Fairness) *State {
+	s := NewWithDeck(rules, deck, 1)
+	for _, id := range []string{"p0", "p1", "p2"} {
+		s.AddPlayer(id, 0)
+	}
+	s.Reseed(f.Seed())
+	s.ResetFrom(f.Base(rules, deck), 7)
+	return s
+}
+
+func TestVerify(t *testing.T) {
+	custom := &Deck{Cards: []DeckCard{
+		{Name: "red-1", Type: "number", Color: "red", Value: "1", Copies: 20},
+		{Name: "blue-2", Type: "number", Color: "blue", Value: "2", Copies: 20},
+	}}
+
+	tests := []struct {
+		name  string
+		rules Rules
+		deck  *Deck
+	}{
+		{"default deck", Rules{}, nil},
+		{"custom deck", Rules{}, custom},
+		{"flip", Rules{Flip: true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFairness(tt.rules, tt.deck)
+			if err != nil {
+				t.Fatal(err)
+			}
+			commitment := f.Commitment
+			f.Mix("p0:123")
+			f.Mix("anything random")
+			dealt := testFairDeal(tt.rules, tt.deck, f)
+
+			result, err := Verify(f.Proof(), commitment, DefaultDeck, custom)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if !result.Committed || result.Commitment != commitment {
+				t.Errorf("commitment %s doesn't match %s", result.Commitment, commitment)
+			}
+			if result.Seed != f.Seed() || result.DeckHash != dealt.DeckHash {
+				t.Errorf("seed %d and deck %s, want %d and %s", result.Seed, result.DeckHash, f.Seed(), dealt.DeckHash)
+			}
+
+			// Another server seed can't match the commitment
+			forged := strings.Replace(f.Proof(), f.ServerSeed, strings.Repeat("0", len(f.ServerSeed)), 1)
+			if result, err := Verify(forged, commitment, DefaultDeck, custom); err != nil || result.Committed {
+				t.Errorf("forged proof: committed = %v, err = %v, want a mismatch", result != nil && result.Committed, err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	custom := &Deck{Cards: []DeckCard{{Name: "red-1", Type: "number", Color: "red", Value: "1", Copies: 20}}}
+	f, err := NewFairness(Rules{}, custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Verify(f.Proof(), f.Commitment, DefaultDeck); err != ErrUnknownDeck {
+		t.Errorf("deck the server doesn't have: err = %v, want %v", err, ErrUnknownDeck)
+	}
+	for _, proof := range []string{"", "nonsense", "a.b", ".seed.", "deck..seeds"} {
+		if _, err := Verify(proof, f.Commitment, DefaultDeck, custom); err != ErrInvalidProof {
+			t.Errorf("proof %q: err = %v, want %v", proof, err, ErrInvalidProof)
+		}
+	}
+}
+
+func TestPlayerSeedsChangeTheShuffle(t *testing.T) {
+	f, err := NewFairness(Rules{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment, base := f.Commitment, f.BaseSeed()
+	before := f.Seed()
+
+	f.Mix("p0:123")
+	if f.Seed() == before {
+		t.Error("mixing a seed kept the shuffle seed")
+	}
+	// The commitment only covers the server seed and the base order
+	if f.Commitment != commitment || f.BaseSeed() != base {
+		t.Error("mixing a seed changed the commitment")
+	}
+}
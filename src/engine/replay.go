@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const REPLAY_VERSION int = 1
+
+var ErrInvalidReplay = errors.New("invalid replay")
+
+// Everything needed to play a game again move by move
+type Replay struct {
+	Version  int            `json:"v"`
+	Seed     int64          `json:"seed"`
+	Rules    Rules          `json:"rules"`
+	Deck     *Deck          `json:"deck,omitempty"` // Custom deck of the server, empty for the default deck
+	Base     int64          `json:"base,omitempty"` // Seed of the committed deck order of fair games
+	HandSize int            `json:"hand"`
+	Players  []ReplayPlayer `json:"players"` // In seat order
+	Steps    []Step         `json:"steps"`
+}
+
+type ReplayPlayer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Bot  bool   `json:"bot,omitempty"`
+	Team int    `json:"team,omitempty"`
+}
+
+// Kinds of steps
+const (
+	PlayStep      string = "play"
+	DrawStep      string = "draw"
+	KeepStep      string = "keep"
+	ColorStep     string = "color"
+	SwapStep      string = "swap"
+	UnoStep       string = "uno"
+	CatchStep     string = "catch"
+	ExpireStep    string = "expire"
+	ChallengeStep string = "challenge"
+	LeaveStep     string = "leave"
+	RoundStep     string = "round"
+)
+
+// Action or other change to the state, kept small for the replay file
+type Step struct {
+	Kind      string `json:"k"`
+	Player    string `json:"p,omitempty"`
+	Card      string `json:"c,omitempty"`
+	Color     string `json:"color,omitempty"`
+	Target    string `json:"t,omitempty"`
+	Challenge bool   `json:"y,omitempty"`
+	Turn      int    `json:"turn,omitempty"` // First turn of a new round
+}
+
+// Record an action
+func NewStep(action Action) Step {
+	switch a := action.(type) {
+	case PlayCard:
+		return Step{Kind: PlayStep, Player: a.Player, Card: a.CardID}
+	case DrawCard:
+		return Step{Kind: DrawStep, Player: a.Player}
+	case KeepCard:
+		return Step{Kind: KeepStep, Player: a.Player}
+	case ChooseColor:
+		return Step{Kind: ColorStep, Player: a.Player, Color: a.Color}
+	case SwapHands:
+		return Step{Kind: SwapStep, Player: a.Player, Target: a.Target}
+	case CallUno:
+		return Step{Kind: UnoStep, Player: a.Player}
+	case CatchUno:
+		return Step{Kind: CatchStep, Player: a.Player, Target: a.Target}
+	case ExpireUno:
+		return Step{Kind: ExpireStep, Player: a.Player}
+	case Challenge:
+		return Step{Kind: ChallengeStep, Player: a.Player, Challenge: a.Challenge}
+	}
+	return Step{}
+}
+
+// Action the step was recorded from, nil for leaves and new rounds
+func (st Step) Action() Action {
+	switch st.Kind {
+	case PlayStep:
+		return PlayCard{Player: st.Player, CardID: st.Card}
+	case DrawStep:
+		return DrawCard{Player: st.Player}
+	case KeepStep:
+		return KeepCard{Player: st.Player}
+	case ColorStep:
+		return ChooseColor{Player: st.Player, Color: st.Color}
+	case SwapStep:
+		return SwapHands{Player: st.Player, Target: st.Target}
+	case UnoStep:
+		return CallUno{Player: st.Player}
+	case CatchStep:
+		return CatchUno{Player: st.Player, Target: st.Target}
+	case ExpireStep:
+		return ExpireUno{Player: st.Player}
+	case ChallengeStep:
+		return Challenge{Player: st.Player, Challenge: st.Challenge}
+	}
+	return nil
+}
+
+// Read a replay file
+func ParseReplay(data []byte) (*Replay, error) {
+	replay := &Replay{}
+	if err := json.Unmarshal(data, replay); err != nil {
+		return nil, err
+	}
+	if replay.Version != REPLAY_VERSION || len(replay.Players) == 0 || replay.HandSize < 0 {
+		return nil, ErrInvalidReplay
+	}
+	if replay.Deck != nil {
+		if err := replay.Deck.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return replay, nil
+}
+
+// Deal the first round the same way the game did
+func (r *Replay) Start() *State {
+	s := NewWithDeck(r.Rules, r.Deck, r.Seed)
+	for _, player := range r.Players {
+		s.AddPlayer(player.ID, 0).Team = player.Team
+	}
+	s.Reseed(r.Seed)
+	if r.Base != 0 {
+		s.ResetFrom(BaseDeck(r.Rules, r.Deck, r.Base), r.HandSize)
+	} else {
+		s.Reset(r.HandSize, 0)
+	}
+	return s
+}
+
+// Apply the next step to the state
+func (r *Replay) Play(s *State, step Step) ([]Event, error) {
+	switch step.Kind {
+	case LeaveStep:
+		return s.RemovePlayer(step.Player), nil
+	case RoundStep:
+		s.Reset(r.HandSize, step.Turn)
+		return nil, nil
+	}
+
+	action := step.Action()
+	if action == nil {
+		return nil, ErrInvalidReplay
+	}
+	return s.Apply(action)
+}
+
+// Play every step, calling fn with the state and events after each one
+func (r *Replay) Walk(fn func(s *State, step Step, events []Event)) error {
+	s := r.Start()
+	for _, step := range r.Steps {
+		events, err := r.Play(s, step)
+		if err != nil {
+			return err
+		}
+		fn(s, step, events)
+	}
+	return nil
+}
+
+// State after the first n steps, the events of the last step and an error if the replay doesn't fit
+func (r *Replay) Seek(n int) (*State, []Event, error) {
+	s := r.Start()
+	var events []Event
+	for _, step := range r.Steps[:n] {
+		var err error
+		events, err = r.Play(s, step)
+		if err != nil {
+			return s, nil, err
+		}
+	}
+	return s, events, nil
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Deal a game the way the bot does, every player joins with no cards and the first round is dealt from seed
+func testDeal(rules Rules, seed int64, players int) *State {
+	s := NewWithRules(rules, seed)
+	for index := 0; index < players; index++ {
+		s.AddPlayer(fmt.Sprintf("p%d", index), 0)
+	}
+	s.Reseed(seed)
+	s.Reset(7, 0)
+	return s
+}
+
+func cardIDs(cards []Card) []string {
+	var ids []string
+	for _, card := range cards {
+		ids = append(ids, card.ID+":"+card.Name)
+	}
+	return ids
+}
+
+func TestSeedDealsTheSameGame(t *testing.T) {
+	for _, rules := range []Rules{{}, {Flip: true}} {
+		t.Run(fmt.Sprintf("flip=%v", rules.Flip), func(t *testing.T) {
+			a := testDeal(rules, 42, 4)
+			b := testDeal(rules, 42, 4)
+
+			if a.DeckHash != b.DeckHash {
+				t.Errorf("deck hash %s and %s from the same seed", a.DeckHash, b.DeckHash)
+			}
+			if !reflect.DeepEqual(cardIDs(a.Deck), cardIDs(b.Deck)) {
+				t.Error("deck order differs with the same seed")
+			}
+			if a.TopCard().ID != b.TopCard().ID {
+				t.Errorf("top card %s and %s from the same seed", a.TopCard().Name, b.TopCard().Name)
+			}
+			for index := range a.Players {
+				if !reflect.DeepEqual(cardIDs(a.Players[index].Hand), cardIDs(b.Players[index].Hand)) {
+					t.Errorf("hand of %s differs with the same seed", a.Players[index].ID)
+				}
+			}
+
+			if other := testDeal(rules, 43, 4); other.DeckHash == a.DeckHash {
+				t.Error("another seed dealt the same deck")
+			}
+		})
+	}
+}
+
+// Simple player for the round trip, plays the first legal card or draws
+func testAction(s *State) Action {
+	player := s.CurrentPlayer()
+	switch s.Phase {
+	case TurnPhase:
+		for _, card := range player.Hand {
+			if s.CanPlay(card) {
+				return PlayCard{Player: player.ID, CardID: card.ID}
+			}
+		}
+		return DrawCard{Player: player.ID}
+	case KeepPhase:
+		if s.CanPlay(*s.Drawn) {
+			return PlayCard{Player: player.ID, CardID: s.Drawn.ID}
+		}
+		return KeepCard{Player: player.ID}
+	case ColorPhase:
+		return ChooseColor{Player: player.ID, Color: s.ActiveColors()[len(s.DiscardPile)%4]}
+	case ChallengePhase:
+		challenger := s.NextPlayer()
+		return Challenge{Player: challenger.ID, Challenge: len(challenger.Hand)%2 == 0}
+	case SwapPhase:
+		return SwapHands{Player: player.ID, Target: s.NextPlayer().ID}
+	}
+	return nil
+}
+
+func TestReplayRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		seed  int64
+		base  int64 // Committed deck order of fair games
+	}{
+		{"official", Rules{}, 1, 0},
+		{"house rules", Rules{Stacking: true, StackDrawFourOnDrawTwo: true, SevenO: true}, 2, 0},
+		{"flip", Rules{Flip: true}, 3, 0},
+		{"fair", Rules{}, 4, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay := &Replay{Version: REPLAY_VERSION, Seed: tt.seed, Base: tt.base, Rules: tt.rules, HandSize: 7}
+			for index := 0; index < 3; index++ {
+				replay.Players = append(replay.Players, ReplayPlayer{ID: fmt.Sprintf("p%d", index)})
+			}
+
+			// Play the game and record every step
+			live := replay.Start()
+			for len(replay.Steps) < 2000 && live.Phase != OverPhase {
+				action := testAction(live)
+				if _, err := live.Apply(action); err != nil {
+					t.Fatalf("step %d %T: %v", len(replay.Steps), action, err)
+				}
+				replay.Steps = append(replay.Steps, NewStep(action))
+
+				// Someone leaves halfway
+				if len(replay.Steps) == 20 {
+					live.RemovePlayer("p2")
+					replay.Steps = append(replay.Steps, Step{Kind: LeaveStep, Player: "p2"})
+				}
+			}
+
+			data, err := json.Marshal(replay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := ParseReplay(data)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			replayed, _, err := parsed.Seek(len(parsed.Steps))
+			if err != nil {
+				t.Fatalf("seek: %v", err)
+			}
+
+			if !reflect.DeepEqual(cardIDs(replayed.Deck), cardIDs(live.Deck)) {
+				t.Error("deck differs after the replay")
+			}
+			if !reflect.DeepEqual(cardIDs(replayed.DiscardPile), cardIDs(live.DiscardPile)) {
+				t.Error("discard pile differs after the replay")
+			}
+			if len(replayed.Players) != len(live.Players) {
+				t.Fatalf("%d players after the replay, want %d", len(replayed.Players), len(live.Players))
+			}
+			for index, player := range live.Players {
+				if !reflect.DeepEqual(cardIDs(replayed.Players[index].Hand), cardIDs(player.Hand)) {
+					t.Errorf("hand of %s differs after the replay", player.ID)
+				}
+			}
+			if replayed.CurrentTurn != live.CurrentTurn || replayed.Phase != live.Phase || replayed.Color != live.Color ||
+				replayed.Reversed != live.Reversed || replayed.Dark != live.Dark || replayed.Penalty != live.Penalty {
+				t.Error("turn, phase or color differs after the replay")
+			}
+			if (replayed.Winner == nil) != (live.Winner == nil) || (live.Winner != nil && replayed.Winner.ID != live.Winner.ID) {
+				t.Error("winner differs after the replay")
+			}
+		})
+	}
+}
@@ -0,0 +1,552 @@
+package engine
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+)
+
+// Phase describes what the engine is waiting for
+type Phase int
+
+const (
+	// Current player has to play or draw a card
+	TurnPhase Phase = iota
+	// Current player drew a card and has to keep or play it
+	KeepPhase
+	// Current player played a wild card and has to pick a color
+	ColorPhase
+	// Next player can challenge the Wild Draw Four
+	ChallengePhase
+	// Current player played a 7 and has to pick someone to swap hands with
+	SwapPhase
+	// Someone won, no more actions are accepted
+	OverPhase
+)
+
+var (
+	ErrGameOver      = errors.New("the game is over")
+	ErrNotYourTurn   = errors.New("it's not your turn")
+	ErrWrongPhase    = errors.New("that action is not allowed right now")
+	ErrCardNotInHand = errors.New("you don't have that card")
+	ErrCannotPlay    = errors.New("that card can't be played on the current card")
+	ErrInvalidColor  = errors.New("invalid color")
+	ErrUnknownPlayer = errors.New("unknown player")
+	ErrInvalidTarget = errors.New("you can't swap hands with that player")
+	ErrNoUno         = errors.New("nobody needs to call UNO")
+)
+
+// Valid colors for wild cards
+var Colors = []string{"red", "green", "blue", "yellow"}
+
+type Player struct {
+	ID   string
+	Hand []Card
+	Team int // Partners share a team, 0 plays alone
+}
+
+// House rules, all off by default
+type Rules struct {
+	// Draw Two and Wild Draw Four can be answered with another one to pass the penalty on
+	Stacking bool
+	// Wild Draw Four can be stacked on a Draw Two
+	StackDrawFourOnDrawTwo bool
+	// Playing a 7 swaps hands with another player, a 0 rotates all hands
+	SevenO bool
+	// Anyone holding an exact duplicate of the top card can play it out of turn
+	JumpIn bool
+	// Players with the same Team play together and win together
+	Partners bool
+	// Play with the double-sided UNO Flip deck
+	Flip bool
+}
+
+// Wild Draw Four waiting to be challenged
+type PendingChallenge struct {
+	Player        string // Player who played the Wild Draw Four
+	PreviousColor string // Active color before the Wild Draw Four
+}
+
+// State holds everything needed to run the rules of a game
+type State struct {
+	Deck        []Card
+	DiscardPile []Card
+	Players     []*Player
+	CurrentTurn int
+	Reversed    bool
+	Color       string // Active color, set by the top card or a wild card
+	Phase       Phase
+	Drawn       *Card // Card drawn this turn, waiting to be kept or played
+	Challenge   *PendingChallenge
+	Winner      *Player
+	Rules       Rules
+	Penalty     int    // Cards the current player draws unless they stack
+	UnoPending  string // Player down to one card who hasn't called UNO yet
+	Uncalled    string // Player who didn't call UNO in time, they can be caught until the next player acts
+	Dark        bool   // UNO Flip dark side is up
+	Seed        int64  // Every shuffle comes from this seed, the same seed and moves replay the same game
+	DeckHash    string // Hash of the shuffled deck order before the first card was dealt
+	CustomDeck  *Deck  // Deck definition of the server, nil plays with DefaultDeck
+	rng         *rand.Rand
+}
+
+// Create a new state with a shuffled deck and a number card on the discard pile
+func New() *State {
+	return NewWithRules(Rules{}, rand.Int63())
+}
+
+// Create a new state with the deck the rules ask for, shuffled from the seed
+func NewWithRules(rules Rules, seed int64) *State {
+	return NewWithDeck(rules, nil, seed)
+}
+
+// Create a new state playing with a custom deck definition, nil uses DefaultDeck
+func NewWithDeck(rules Rules, deck *Deck, seed int64) *State {
+	s := &State{
+		Rules:      rules,
+		Seed:       seed,
+		CustomDeck: deck,
+		rng:        rand.New(rand.NewSource(seed)),
+	}
+	s.newDeck()
+	return s
+}
+
+// Deck definition the state plays with
+func (s *State) Definition() *Deck {
+	if s.CustomDeck != nil {
+		return s.CustomDeck
+	}
+	return DefaultDeck
+}
+
+// Unshuffled cards of the deck the rules and definition ask for
+func (s *State) generate() []Card {
+	if s.Rules.Flip {
+		return GenerateFlipDeck(s.rng)
+	}
+	return s.Definition().Generate()
+}
+
+// Deck order shuffled from the seed alone, fair games commit to it before the players add their seeds
+func BaseDeck(rules Rules, deck *Deck, seed int64) []Card {
+	s := &State{Rules: rules, CustomDeck: deck, rng: rand.New(rand.NewSource(seed))}
+	cards := s.generate()
+	ShuffleDeck(cards, s.rng)
+	return cards
+}
+
+// Shuffle a fresh deck and turn a number card over on the discard pile
+func (s *State) newDeck() {
+	s.shuffleIn(s.generate())
+}
+
+// Shuffle the cards into a new deck and turn a number card over on the discard pile
+func (s *State) shuffleIn(cards []Card) {
+	s.Deck = cards
+	s.Phase = TurnPhase
+
+	ShuffleDeck(s.Deck, s.rng)
+
+	// Ensure the first card is a number card
+	for s.Deck[0].Type != NumberCard {
+		ShuffleDeck(s.Deck, s.rng)
+	}
+
+	s.DeckHash = HashDeck(s.Deck)
+	s.DiscardPile = append(s.DiscardPile, s.Deck[0])
+	s.Deck = s.Deck[1:]
+	s.Color = s.TopCard().Color
+}
+
+// Shuffle from a new seed from now on
+func (s *State) Reseed(seed int64) {
+	s.Seed = seed
+	s.rng = rand.New(rand.NewSource(seed))
+}
+
+// Start a new round with a fresh deck, keeping players and rules.
+// The shuffles carry on from the same seed.
+func (s *State) Reset(handSize int, firstTurn int) {
+	s.reset(nil, handSize, firstTurn)
+}
+
+// Start the first round from a committed deck order, shuffled again from the seed
+func (s *State) ResetFrom(base []Card, handSize int) {
+	s.reset(base, handSize, 0)
+}
+
+func (s *State) reset(base []Card, handSize int, firstTurn int) {
+	fresh := &State{
+		Players:    s.Players,
+		Rules:      s.Rules,
+		Seed:       s.Seed,
+		CustomDeck: s.CustomDeck,
+		rng:        s.rng,
+	}
+	if base != nil {
+		fresh.shuffleIn(slices.Clone(base))
+	} else {
+		fresh.newDeck()
+	}
+	fresh.CurrentTurn = firstTurn % len(s.Players)
+	*s = *fresh
+
+	for _, player := range s.Players {
+		player.Hand = s.draw(handSize)
+	}
+}
+
+// Points the winner scores from the cards left in everyone elses hand
+func (s *State) RoundPoints() int {
+	points := 0
+	for _, player := range s.Players {
+		if player == s.Winner || s.Teammates(player, s.Winner) {
+			continue
+		}
+		for _, card := range player.Hand {
+			points += card.Points()
+		}
+	}
+	return points
+}
+
+// Reorder the seats, players missing from order keep their place at the end
+func (s *State) Arrange(order []string) {
+	var players []*Player
+	for _, id := range order {
+		if player := s.Player(id); player != nil {
+			players = append(players, player)
+		}
+	}
+	for _, player := range s.Players {
+		if !contains(order, player.ID) {
+			players = append(players, player)
+		}
+	}
+	s.Players = players
+}
+
+// Check if two players are partners
+func (s *State) Teammates(a *Player, b *Player) bool {
+	return s.Rules.Partners && a != nil && b != nil && a.Team != 0 && a.Team == b.Team
+}
+
+// Add a player and deal their starting hand
+func (s *State) AddPlayer(id string, handSize int) *Player {
+	player := &Player{
+		ID:   id,
+		Hand: s.draw(handSize),
+	}
+	s.Players = append(s.Players, player)
+	return player
+}
+
+// Remove a player and shuffle their hand back into the deck.
+// Whatever the player still had to do is dropped and the turn moves on if it was theirs.
+func (s *State) RemovePlayer(id string) []Event {
+	player := s.Player(id)
+	if player == nil {
+		return nil
+	}
+
+	s.Deck = append(s.Deck, player.Hand...)
+	ShuffleDeck(s.Deck, s.rng)
+	player.Hand = nil
+
+	if s.UnoPending == id {
+		s.UnoPending = ""
+	}
+	if s.Uncalled == id {
+		s.Uncalled = ""
+	}
+
+	seat := s.seat(player)
+	wasCurrent := seat == s.CurrentTurn
+	events := []Event{PlayerLeft{Player: id}}
+
+	// A Wild Draw Four challenge between the leaver and someone else is called off
+	if s.Phase == ChallengePhase && (wasCurrent || s.NextPlayer() == player) {
+		s.Challenge = nil
+		s.Phase = TurnPhase
+		// If the challenger left the Wild Draw Four player's turn is over as well
+		s.CurrentTurn = seat
+		wasCurrent = true
+	}
+
+	s.Players = append(s.Players[:seat:seat], s.Players[seat+1:]...)
+	if len(s.Players) == 0 {
+		s.CurrentTurn = 0
+		return events
+	}
+
+	switch {
+	case seat < s.CurrentTurn:
+		s.CurrentTurn--
+	case wasCurrent:
+		// The player after the leaver now sits in their seat
+		if s.Reversed {
+			seat--
+		}
+		s.CurrentTurn = (seat + len(s.Players)) % len(s.Players)
+
+		s.Drawn = nil
+		if s.Phase == TurnPhase {
+			// The penalty was theirs to take, it isn't passed on
+			s.Penalty = 0
+		}
+		if s.Phase == ColorPhase {
+			s.Color = s.ActiveColors()[0]
+		}
+		s.Challenge = nil
+		if s.Phase != OverPhase {
+			s.Phase = TurnPhase
+		}
+		events = append(events, TurnStarted{Player: s.CurrentPlayer().ID})
+	}
+	s.CurrentTurn %= len(s.Players)
+
+	return events
+}
+
+// Get player with id
+func (s *State) Player(id string) *Player {
+	for _, player := range s.Players {
+		if player.ID == id {
+			return player
+		}
+	}
+	return nil
+}
+
+// Get current player
+func (s *State) CurrentPlayer() *Player {
+	return s.Players[s.CurrentTurn]
+}
+
+// Get next player in the current direction
+func (s *State) NextPlayer() *Player {
+	return s.Players[s.offset(1)]
+}
+
+// Get the card on top of the discard pile
+func (s *State) TopCard() Card {
+	return s.DiscardPile[len(s.DiscardPile)-1]
+}
+
+// Check if a card can be played on top of the discard pile
+func (s *State) CanPlay(card Card) bool {
+	if s.Penalty > 0 {
+		return s.CanStack(card)
+	}
+
+	return card.Matches(s.TopCard(), s.Color)
+}
+
+// Check if a card can be stacked on the pending penalty
+func (s *State) CanStack(card Card) bool {
+	if s.Penalty == 0 {
+		return false
+	}
+
+	// Only the same draw card stacks, a Wild Draw Four on a Draw Two is an extra house rule
+	topCard := s.TopCard()
+	if card.Type == topCard.Type {
+		return topCard.DrawAmount() > 0
+	}
+	return topCard.Type == DrawTwoCard && card.Type == WildDrawFourCard && s.Rules.StackDrawFourOnDrawTwo
+}
+
+// Check if a card can be played out of turn on an identical top card
+func (s *State) CanJumpIn(card Card) bool {
+	if !s.Rules.JumpIn || s.Phase != TurnPhase || s.Penalty > 0 {
+		return false
+	}
+	if card.IsWild() {
+		return false
+	}
+
+	topCard := s.TopCard()
+	return card.Type == topCard.Type && card.Color == topCard.Color && card.Value == topCard.Value
+}
+
+// Colors of the side that is up
+func (s *State) ActiveColors() []string {
+	if s.Dark {
+		return DarkColors
+	}
+	return Colors
+}
+
+// Seat index of the player
+func (s *State) seat(player *Player) int {
+	for i, p := range s.Players {
+		if p == player {
+			return i
+		}
+	}
+	return -1
+}
+
+// Index of player n seats away from the current player
+func (s *State) offset(n int) int {
+	if s.Reversed {
+		n = -n
+	}
+	count := len(s.Players)
+	return ((s.CurrentTurn+n)%count + count) % count
+}
+
+// Moves turn to the next player
+func (s *State) nextTurn() Event {
+	s.CurrentTurn = s.offset(1)
+	return TurnStarted{Player: s.CurrentPlayer().ID}
+}
+
+// Skips the next player and moves turn to the one after
+func (s *State) skipTurn() []Event {
+	skipped := s.NextPlayer()
+	s.CurrentTurn = s.offset(1)
+	return []Event{TurnSkipped{Player: skipped.ID}, s.nextTurn()}
+}
+
+// Draw cards from deck
+func (s *State) draw(num int) []Card {
+	if len(s.Deck) < num {
+		// Reshuffle discard pile into deck if possible
+		if len(s.DiscardPile) > 1 { // Must leave 1 card in DiscardPile
+			s.Deck = append(s.Deck, s.DiscardPile[:len(s.DiscardPile)-1]...)
+			s.DiscardPile = []Card{s.TopCard()}
+
+			ShuffleDeck(s.Deck, s.rng) // Shuffle the new deck
+		}
+	}
+
+	// Adjust number of cards to draw
+	if len(s.Deck) < num {
+		num = len(s.Deck)
+	}
+
+	// Copy so hands never share memory with the deck
+	drawn := append([]Card{}, s.Deck[:num]...)
+	s.Deck = s.Deck[num:]
+	return drawn
+}
+
+// Give a player cards from the deck
+func (s *State) give(player *Player, num int) Event {
+	cards := s.draw(num)
+	player.Hand = append(player.Hand, cards...)
+	return CardsDrawn{Player: player.ID, Cards: cards}
+}
+
+// Draw cards one by one until one of the color shows up or the deck runs out
+func (s *State) drawUntil(player *Player, color string) Event {
+	var cards []Card
+	for {
+		drawn := s.draw(1)
+		if len(drawn) == 0 {
+			break
+		}
+		cards = append(cards, drawn[0])
+		if drawn[0].Color == color {
+			break
+		}
+	}
+	player.Hand = append(player.Hand, cards...)
+	return CardsDrawn{Player: player.ID, Cards: cards}
+}
+
+// Give the player the cards the wild draw card on top makes them draw, or the stacked penalty, plus extra cards
+func (s *State) drawPenalty(player *Player, extra int) []Event {
+	if s.Penalty > 0 {
+		penalty := s.Penalty
+		s.Penalty = 0
+		return []Event{s.give(player, penalty+extra)}
+	}
+
+	topCard := s.TopCard()
+	if topCard.Type != WildDrawColorCard {
+		return []Event{s.give(player, topCard.DrawAmount()+extra)}
+	}
+
+	events := []Event{s.drawUntil(player, s.Color)}
+	if extra > 0 {
+		events = append(events, s.give(player, extra))
+	}
+	return events
+}
+
+// Turn every card in the game over to the other side
+func (s *State) flip() Event {
+	s.Dark = !s.Dark
+	for _, pile := range [][]Card{s.Deck, s.DiscardPile} {
+		for i := range pile {
+			pile[i].Flip()
+		}
+	}
+	for _, player := range s.Players {
+		for i := range player.Hand {
+			player.Hand[i].Flip()
+		}
+	}
+	s.Color = s.TopCard().Color
+	return Flipped{Dark: s.Dark}
+}
+
+// Index of card in the players hand, -1 if missing
+func (p *Player) cardIndex(cardID string) int {
+	for i, card := range p.Hand {
+		if card.ID == cardID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Remove card at index from the players hand
+func (p *Player) removeCard(index int) Card {
+	card := p.Hand[index]
+	hand := make([]Card, 0, len(p.Hand)-1)
+	hand = append(hand, p.Hand[:index]...)
+	p.Hand = append(hand, p.Hand[index+1:]...)
+	return card
+}
+
+// Pass every hand to the next player in the current direction
+func (s *State) rotateHands() Event {
+	count := len(s.Players)
+	hands := make([][]Card, count)
+	for i, player := range s.Players {
+		hands[i] = player.Hand
+	}
+
+	step := 1
+	if s.Reversed {
+		step = -1
+	}
+	for i, hand := range hands {
+		s.Players[((i+step)%count+count)%count].Hand = hand
+	}
+
+	return HandsRotated{Reversed: s.Reversed}
+}
+
+// Check if the player holds a card of the given color
+func (p *Player) hasColor(color string) bool {
+	for _, card := range p.Hand {
+		if card.Color == color {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(ids []string, id string) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
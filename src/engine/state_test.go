@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Card for tests, IDs only have to be unique within a test
+func testCard(id string, cardType CardType, color string, value string) Card {
+	return Card{ID: id, Face: Face{Name: id, Type: cardType, Color: color, Value: value}}
+}
+
+// Game with a red 5 on top and a hand for every player, p0 is up
+func testState(rules Rules, hands ...[]Card) *State {
+	s := NewWithRules(rules, 1)
+	s.DiscardPile = []Card{testCard("top", NumberCard, "red", "5")}
+	s.Color = "red"
+	for index, hand := range hands {
+		s.Players = append(s.Players, &Player{ID: fmt.Sprintf("p%d", index), Hand: hand})
+	}
+	return s
+}
+
+func TestCanPlay(t *testing.T) {
+	redFive := testCard("top", NumberCard, "red", "5")
+	wild := testCard("top", WildCard, "", "")
+
+	tests := []struct {
+		name  string
+		top   Card
+		color string
+		card  Card
+		want  bool
+	}{
+		{"same color", redFive, "red", testCard("c", NumberCard, "red", "9"), true},
+		{"same number", redFive, "red", testCard("c", NumberCard, "blue", "5"), true},
+		{"nothing matches", redFive, "red", testCard("c", NumberCard, "blue", "7"), false},
+		{"action of the same color", redFive, "red", testCard("c", SkipCard, "red", ""), true},
+		{"action of another color", redFive, "red", testCard("c", SkipCard, "blue", ""), false},
+		{"same action", testCard("top", SkipCard, "red", ""), "red", testCard("c", SkipCard, "blue", ""), true},
+		{"wild", redFive, "red", testCard("c", WildCard, "", ""), true},
+		{"wild draw four", redFive, "red", testCard("c", WildDrawFourCard, "", ""), true},
+		{"picked color", wild, "green", testCard("c", NumberCard, "green", "2"), true},
+		{"other color than picked", wild, "green", testCard("c", NumberCard, "red", "5"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testState(Rules{})
+			s.DiscardPile = []Card{tt.top}
+			s.Color = tt.color
+			if got := s.CanPlay(tt.card); got != tt.want {
+				t.Errorf("CanPlay(%s) = %v, want %v", tt.card.Name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanStack(t *testing.T) {
+	drawTwo := testCard("top", DrawTwoCard, "red", "")
+	drawFour := testCard("top", WildDrawFourCard, "", "")
+
+	tests := []struct {
+		name    string
+		rules   Rules
+		top     Card
+		penalty int
+		card    Card
+		want    bool
+	}{
+		{"no penalty", Rules{Stacking: true}, drawTwo, 0, testCard("c", DrawTwoCard, "blue", ""), false},
+		{"draw two on draw two", Rules{Stacking: true}, drawTwo, 2, testCard("c", DrawTwoCard, "blue", ""), true},
+		{"draw four on draw four", Rules{Stacking: true}, drawFour, 4, testCard("c", WildDrawFourCard, "", ""), true},
+		{"draw four on draw two", Rules{Stacking: true}, drawTwo, 2, testCard("c", WildDrawFourCard, "", ""), false},
+		{"draw four on draw two with the house rule", Rules{Stacking: true, StackDrawFourOnDrawTwo: true}, drawTwo, 2, testCard("c", WildDrawFourCard, "", ""), true},
+		{"draw two on draw four", Rules{Stacking: true, StackDrawFourOnDrawTwo: true}, drawFour, 4, testCard("c", DrawTwoCard, "red", ""), false},
+		{"same color number", Rules{Stacking: true}, drawTwo, 2, testCard("c", NumberCard, "red", "3"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testState(tt.rules)
+			s.DiscardPile = []Card{tt.top}
+			s.Penalty = tt.penalty
+			if got := s.CanStack(tt.card); got != tt.want {
+				t.Errorf("CanStack(%s) = %v, want %v", tt.card.Name, got, tt.want)
+			}
+			// A pending penalty only allows stacking
+			if tt.penalty > 0 && s.CanPlay(tt.card) != tt.want {
+				t.Errorf("CanPlay(%s) = %v with a penalty, want %v", tt.card.Name, !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanJumpIn(t *testing.T) {
+	identical := testCard("c", NumberCard, "red", "5")
+
+	tests := []struct {
+		name    string
+		rules   Rules
+		top     Card
+		phase   Phase
+		penalty int
+		card    Card
+		want    bool
+	}{
+		{"identical card", Rules{JumpIn: true}, identical, TurnPhase, 0, identical, true},
+		{"identical action", Rules{JumpIn: true}, testCard("top", SkipCard, "blue", ""), TurnPhase, 0, testCard("c", SkipCard, "blue", ""), true},
+		{"rule off", Rules{}, identical, TurnPhase, 0, identical, false},
+		{"other color", Rules{JumpIn: true}, identical, TurnPhase, 0, testCard("c", NumberCard, "blue", "5"), false},
+		{"other number", Rules{JumpIn: true}, identical, TurnPhase, 0, testCard("c", NumberCard, "red", "6"), false},
+		{"wild", Rules{JumpIn: true}, testCard("top", WildCard, "", ""), TurnPhase, 0, testCard("c", WildCard, "", ""), false},
+		{"color being picked", Rules{JumpIn: true}, identical, ColorPhase, 0, identical, false},
+		{"penalty pending", Rules{JumpIn: true, Stacking: true}, testCard("top", DrawTwoCard, "red", ""), TurnPhase, 2, testCard("c", DrawTwoCard, "red", ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testState(tt.rules)
+			s.DiscardPile = []Card{tt.top}
+			s.Phase = tt.phase
+			s.Penalty = tt.penalty
+			if got := s.CanJumpIn(tt.card); got != tt.want {
+				t.Errorf("CanJumpIn(%s) = %v, want %v", tt.card.Name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemovePlayer(t *testing.T) {
+	tests := []struct {
+		name     string
+		players  int
+		turn     int
+		reversed bool
+		remove   string
+		want     string // Current player afterwards, empty when nobody is left
+		started  bool   // A new turn started
+	}{
+		{"current player", 4, 1, false, "p1", "p2", true},
+		{"current player reversed", 4, 1, true, "p1", "p0", true},
+		{"current player in the last seat", 4, 3, false, "p3", "p0", true},
+		{"before the current seat", 4, 1, false, "p0", "p1", false},
+		{"after the current seat", 4, 1, false, "p3", "p1", false},
+		{"last player", 1, 0, false, "p0", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hands [][]Card
+			for index := 0; index < tt.players; index++ {
+				hands = append(hands, []Card{
+					testCard(fmt.Sprintf("a%d", index), NumberCard, "blue", "1"),
+					testCard(fmt.Sprintf("b%d", index), NumberCard, "blue", "2"),
+				})
+			}
+			s := testState(Rules{}, hands...)
+			s.CurrentTurn = tt.turn
+			s.Reversed = tt.reversed
+			deck := len(s.Deck)
+
+			events := s.RemovePlayer(tt.remove)
+
+			if len(s.Players) != tt.players-1 || s.Player(tt.remove) != nil {
+				t.Fatalf("%s is still seated", tt.remove)
+			}
+			if len(s.Deck) != deck+2 {
+				t.Errorf("deck has %d cards, want the %d cards of the leaver back", len(s.Deck), deck+2)
+			}
+			if tt.want == "" {
+				if s.CurrentTurn != 0 {
+					t.Errorf("current turn = %d with nobody left, want 0", s.CurrentTurn)
+				}
+			} else if got := s.CurrentPlayer().ID; got != tt.want {
+				t.Errorf("current player = %s, want %s", got, tt.want)
+			}
+
+			started := false
+			for _, event := range events {
+				if _, ok := event.(TurnStarted); ok {
+					started = true
+				}
+			}
+			if started != tt.started {
+				t.Errorf("turn started = %v, want %v", started, tt.started)
+			}
+		})
+	}
+}
+
+func TestRoundPoints(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		teams []int // Team of every player
+		want  int
+	}{
+		{"everyone else", Rules{}, []int{0, 0, 0}, 7 + 20 + 50},
+		{"partners", Rules{Partners: true}, []int{1, 2, 1}, 7 + 20},
+		{"teams without the rule", Rules{}, []int{1, 2, 1}, 7 + 20 + 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testState(tt.rules,
+				nil,
+				[]Card{testCard("seven", NumberCard, "red", "7"), testCard("skip", SkipCard, "blue", "")},
+				[]Card{testCard("wild", WildCard, "", "")},
+			)
+			for index, team := range tt.teams {
+				s.Players[index].Team = team
+			}
+			s.Winner = s.Players[0]
+
+			if got := s.RoundPoints(); got != tt.want {
+				t.Errorf("RoundPoints() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
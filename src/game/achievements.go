@@ -0,0 +1,179 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/Ranzz02/uno-discord-bot/src/store"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Unlocked achievements by "<guild id>/<user id>"
+	AchievementsBucket string = "achievements"
+	// Hand size a winner has to come back from
+	COMEBACK_CARDS int = 15
+)
+
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+	earned      func(g *Game, player *Player, sum *summary) bool
+}
+
+// Every achievement, in the order they are listed
+var Achievements = []Achievement{
+	{
+		ID:          "wild_finish",
+		Name:        "🃏 Wild Finish",
+		Description: "Win a game by playing a Wild Draw Four as your last card",
+		earned: func(g *Game, player *Player, sum *summary) bool {
+			return player == g.Winner && sum.WonWith != nil && sum.WonWith.Type == engine.WildDrawFourCard
+		},
+	},
+	{
+		ID:          "objection",
+		Name:        "⚖️ Objection!",
+		Description: "Win a Wild Draw Four challenge",
+		earned: func(g *Game, player *Player, sum *summary) bool {
+			return sum.ChallengesWon > 0
+		},
+	},
+	{
+		ID:          "comeback",
+		Name:        "📈 Comeback",
+		Description: fmt.Sprintf("Go out to win a game after holding %d or more cards", COMEBACK_CARDS),
+		earned: func(g *Game, player *Player, sum *summary) bool {
+			return player == g.Winner && sum.MostCards >= COMEBACK_CARDS
+		},
+	},
+	{
+		ID:          "clean_sweep",
+		Name:        "✨ Clean Sweep",
+		Description: "Go out to win a game without drawing a single card",
+		earned: func(g *Game, player *Player, sum *summary) bool {
+			return player == g.Winner && !sum.Drew
+		},
+	},
+}
+
+// Achievements a player unlocked in one guild
+type Unlocks struct {
+	UserID   string
+	Unlocked map[string]time.Time // By achievement ID
+}
+
+// Achievements a player unlocked, keyed like stats
+func loadUnlocks(guildID string, userID string) (*Unlocks, error) {
+	unlocks := &Unlocks{UserID: userID}
+	_, err := store.Get(AchievementsBucket, statsKey(guildID, userID), unlocks)
+	if unlocks.Unlocked == nil {
+		unlocks.Unlocked = map[string]time.Time{}
+	}
+	return unlocks, err
+}
+
+// Save the achievements human players earned this game, returns the new ones per player
+func (g *Game) unlockAchievements(summaries map[string]*summary) map[*Player][]Achievement {
+	unlocked := map[*Player][]Achievement{}
+	if g.GuildID == "" {
+		return unlocked
+	}
+
+	statsMux.Lock()
+	defer statsMux.Unlock()
+
+	for _, player := range g.Players {
+		sum := summaries[player.User.ID]
+		if player.IsBot() || sum == nil {
+			continue
+		}
+
+		unlocks, err := loadUnlocks(g.GuildID, player.User.ID)
+		if err != nil {
+			log.Printf("Failed to load achievements of %s: %v", player.User.ID, err)
+			continue
+		}
+
+		for _, achievement := range Achievements {
+			if _, ok := unlocks.Unlocked[achievement.ID]; ok || !achievement.earned(g, player, sum) {
+				continue
+			}
+			unlocks.Unlocked[achievement.ID] = time.Now()
+			unlocked[player] = append(unlocked[player], achievement)
+		}
+
+		if len(unlocked[player]) == 0 {
+			continue
+		}
+		if err := store.Put(AchievementsBucket, statsKey(g.GuildID, player.User.ID), unlocks); err != nil {
+			log.Printf("Failed to save achievements of %s: %v", player.User.ID, err)
+		}
+	}
+	return unlocked
+}
+
+// Congratulate everyone who unlocked something, in seat order
+func (g *Game) announceUnlocks(s *discordgo.Session, unlocked map[*Player][]Achievement) {
+	var lines []string
+	for _, player := range g.Players {
+		for _, achievement := range unlocked[player] {
+			lines = append(lines, fmt.Sprintf("%s unlocked **%s**: %s", g.Mention(player.User.ID), achievement.Name, achievement.Description))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	_, err := s.ChannelMessageSendEmbed(g.ChannelID, &discordgo.MessageEmbed{
+		Title:       "🏅 Achievements unlocked",
+		Description: strings.Join(lines, "\n"),
+		Color:       0xFFD700,
+	})
+	if err != nil {
+		log.Printf("Failed to announce achievements: %v", err)
+	}
+}
+
+// Show which achievements a player earned in the guild
+func ShowAchievements(s *discordgo.Session, i *discordgo.InteractionCreate, userID string) {
+	unlocks, err := loadUnlocks(i.GuildID, userID)
+	if err != nil {
+		log.Printf("Failed to load achievements of %s: %v", userID, err)
+	}
+
+	var earned, locked []string
+	for _, achievement := range Achievements {
+		if at, ok := unlocks.Unlocked[achievement.ID]; ok {
+			earned = append(earned, fmt.Sprintf("✅ **%s** <t:%d:d>\n%s", achievement.Name, at.Unix(), achievement.Description))
+		} else {
+			locked = append(locked, fmt.Sprintf("🔒 **%s**\n%s", achievement.Name, achievement.Description))
+		}
+	}
+
+	fields := []*discordgo.MessageEmbedField{}
+	if len(earned) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Earned", Value: strings.Join(earned, "\n")})
+	}
+	if len(locked) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Locked", Value: strings.Join(locked, "\n")})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       "🏅 Achievements",
+					Description: fmt.Sprintf("<@%s> earned **%d/%d** achievements on this server.", userID, len(earned), len(Achievements)),
+					Color:       0xFFD700,
+					Fields:      fields,
+				},
+			},
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
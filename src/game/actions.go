@@ -1,143 +1,239 @@
 package game
 
 import (
+	"log"
+	"time"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
 	"github.com/bwmarrin/discordgo"
 )
 
-// Moves turn to the next player
-func (g *Game) NextTurn() {
-	if g.Reversed {
-		g.CurrentTurn--
-		if g.CurrentTurn < 0 {
-			g.CurrentTurn = len(g.Players) - 1
-		}
-	} else {
-		g.CurrentTurn++
-		if g.CurrentTurn >= len(g.Players) {
-			g.CurrentTurn = 0
-		}
-	}
-}
-
 // Tries to play a players card on their turn
 func (g *Game) PlayCard(s *discordgo.Session, i *discordgo.InteractionCreate, cardID string) {
-	g.UNO = false
-	player := g.GetCurrentPlayer()
+	g.Apply(s, i, engine.PlayCard{Player: i.Member.User.ID, CardID: cardID})
+}
 
-	var card *Card
-	for _, c := range player.Hand {
-		if c.ID == cardID {
-			card = &c
+// Draw one card from the deck
+func (g *Game) DrawCard(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	g.KeepCardData.User = i.Member.User.ID
+	g.Apply(s, i, engine.DrawCard{Player: i.Member.User.ID})
+}
+
+// Apply an action to the engine and prompt for whatever it waits on next
+func (g *Game) Apply(s *discordgo.Session, i *discordgo.InteractionCreate, action engine.Action) {
+	first := true
+	for action != nil {
+		err := g.applyAction(s, action)
+		if err != nil {
+			log.Printf("Rejected action %T: %v", action, err)
+			if first {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Data: &discordgo.InteractionResponseData{
+						Content: "Can't do that, " + err.Error() + ".",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+				})
+				return
+			}
 			break
 		}
+		first = false
+		action = g.prompt(s, i)
 	}
 
-	if card == nil {
-		return
+	// Player is active, reset their AFK counter
+	if player := g.GetPlayer(i.Member.User.ID); player != nil && !first {
+		player.Timeouts = 0
 	}
 
-	if !g.CanPlayCard(card) {
-		// TODO: Add error response
-		return
+	g.update(s)
+}
+
+// Apply a single action and react to what happened
+func (g *Game) applyAction(s *discordgo.Session, action engine.Action) error {
+	// Only one action at a time, the loser of a race gets rejected
+	g.engineMux.Lock()
+	if g.State == Ended {
+		g.engineMux.Unlock()
+		return ErrGameEnded
+	}
+	pending := g.Engine.UnoPending
+	events, err := g.Engine.Apply(action)
+	if err == nil {
+		g.recordStep(engine.NewStep(action))
+
+		// Someone went down to one card, give them time to call UNO
+		if g.Engine.UnoPending != "" && g.Engine.UnoPending != pending {
+			g.UnoDeadline = time.Now().Add(UNO_WINDOW)
+			g.expireUno(s, g.Engine.UnoPending, UNO_WINDOW)
+		}
+	}
+	prompting := g.Engine.Phase != engine.TurnPhase && g.Engine.Phase != engine.OverPhase
+	g.engineMux.Unlock()
+	if err != nil {
+		return err
 	}
 
-	newHand := []Card{}
-	for _, handCard := range player.Hand {
-		if handCard.ID == card.ID {
-			g.DiscardPile = append(g.DiscardPile, handCard)
-			continue
+	g.announce(events)
+
+	// Prompts run on their own clock, a new turn gets a new one
+	if prompting {
+		g.StopTurnTimer()
+		return nil
+	}
+	for _, event := range events {
+		if _, ok := event.(engine.TurnStarted); ok {
+			g.StartTurnTimer(s)
+			break
 		}
-		newHand = append(newHand, handCard)
-	}
-	player.Hand = newHand
-
-	switch card.Type {
-	case NumberCard:
-		g.NextTurn()
-	case SkipCard:
-		g.NextTurn()
-		g.NextTurn()
-	case ReverseCard:
-		g.Reversed = !g.Reversed
-		if len(g.Players) == 2 {
-			// 2 Player reverse works as skip
-			g.NextTurn()
+	}
+
+	return nil
+}
+
+// Close the window to call UNO once it runs out, after that the player can be caught
+func (g *Game) expireUno(s *discordgo.Session, player string, after time.Duration) {
+	time.AfterFunc(after, func() {
+		// The player called and went down to one card again, a newer window is running
+		g.engineMux.Lock()
+		running := time.Now().Before(g.UnoDeadline)
+		g.engineMux.Unlock()
+		if running {
+			return
 		}
-		g.NextTurn()
-	case DrawTwoCard:
-		// Force the next player to draw two cards and skip their turn.
-		nextPlayer := g.GetNextPlayer()
-		drawnCards := DrawCards(g, 2)
-		nextPlayer.Hand = append(nextPlayer.Hand, drawnCards...)
-		g.NextTurn()
-		g.NextTurn()
-	case WildCard:
-		// Block until color selection is completed
-		g.ChangeColor(s, i)
-		// Move to the next player's turn.
-		g.NextTurn()
-	case WildDrawFourCard:
-		// Block until color selection is completed
-		g.ChangeColor(s, i)
-		// Challenge draw four
-		challenged := g.ChallengeChoice(s, i)
-		if challenged {
-			player := g.GetCurrentPlayer()
-
-			if player.HasValidPreviousPlay(g) { // If not only valid card draw 4
-				drawnCards := DrawCards(g, 4)
-				player.Hand = append(player.Hand, drawnCards...)
-			} else { // Punish next player and draw 6 cards
-				nextPlayer := g.GetNextPlayer()
-				drawnCards := DrawCards(g, 6)
-				nextPlayer.Hand = append(nextPlayer.Hand, drawnCards...)
-
-				// The challenger loses the challenge and their turn is skipped
-				g.NextTurn()
-			}
-		} else {
-			nextPlayer := g.GetNextPlayer()
-			drawnCards := DrawCards(g, 4)
-			nextPlayer.Hand = append(nextPlayer.Hand, drawnCards...)
 
-			// The challenger loses the challenge and their turn is skipped
-			g.NextTurn()
+		if err := g.applyAction(s, engine.ExpireUno{Player: player}); err != nil {
+			// Called in time, or the round is over
+			return
 		}
+		g.RenderUpdate(s)
+		g.RunBots(s)
+	})
+}
+
+// Answer of a prompt that timed out
+func (g *Game) defaultAnswer() engine.Action {
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+
+	current := g.GetCurrentPlayer()
+
+	switch g.Engine.Phase {
+	case engine.KeepPhase:
+		return engine.KeepCard{Player: current.User.ID}
+	case engine.ColorPhase:
+		return engine.ChooseColor{Player: current.User.ID, Color: g.Engine.ActiveColors()[0]}
+	case engine.SwapPhase:
+		return engine.SwapHands{Player: current.User.ID, Target: g.GetNextPlayer().User.ID}
+	case engine.ChallengePhase:
+		return engine.Challenge{Player: g.GetNextPlayer().User.ID, Challenge: false}
+	}
+	return nil
+}
 
-		// Move to the next player's turn.
-		g.NextTurn()
+// Show the result of actions, scoring the round when someone won
+func (g *Game) update(s *discordgo.Session) {
+	if g.Engine.Phase == engine.OverPhase {
+		g.EndRound(s)
+	} else {
+		// Force a render update after any interaction
+		g.RenderUpdate(s)
 	}
 
-	if len(player.Hand) == 1 {
-		g.UNO = true
+	g.RunBots(s)
+}
+
+// Call UNO, or catch whoever forgot to
+func (g *Game) CallUNO(s *discordgo.Session, i *discordgo.InteractionCreate, catch bool) {
+	if !catch {
+		g.Apply(s, i, engine.CallUno{Player: i.Member.User.ID})
+		return
 	}
 
-	if len(player.Hand) == 0 {
-		g.EndGame(s, player)
+	g.engineMux.Lock()
+	waiting := g.Engine.UnoPending != ""
+	target := g.Engine.Uncalled
+	g.engineMux.Unlock()
+
+	if waiting && target == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "Give them a moment to call UNO first!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
 	}
 
-	// Force a render update after any interaction
-	g.RenderUpdate(s)
+	g.Apply(s, i, engine.CatchUno{Player: i.Member.User.ID, Target: target})
 }
 
-func (g *Game) DrawCard(s *discordgo.Session, i *discordgo.InteractionCreate) {
+// Ask the right player for the engines next input, blocking until answered
+func (g *Game) prompt(s *discordgo.Session, i *discordgo.InteractionCreate) engine.Action {
+	g.engineMux.Lock()
+	current := g.GetCurrentPlayer()
+	phase := g.Engine.Phase
+	g.engineMux.Unlock()
+
+	switch phase {
+	case engine.KeepPhase:
+		// Wait for players response to keep the card or play it.
+		if g.HandleDrawCard(s, i) {
+			return engine.KeepCard{Player: current.User.ID}
+		}
+		return engine.PlayCard{Player: current.User.ID, CardID: g.Engine.Drawn.ID}
+	case engine.ColorPhase:
+		// Block until color selection is completed
+		return engine.ChooseColor{Player: current.User.ID, Color: g.ChangeColor(s, i)}
+	case engine.SwapPhase:
+		// Block until a player to swap with is picked
+		return engine.SwapHands{Player: current.User.ID, Target: g.ChooseSwap(s, i)}
+	case engine.ChallengePhase:
+		// Computer players answer on their own
+		if g.GetNextPlayer().IsBot() {
+			return nil
+		}
+		// Challenge draw four
+		return engine.Challenge{Player: g.GetNextPlayer().User.ID, Challenge: g.ChallengeChoice(s, i)}
+	}
+	return nil
+}
+
+// Ask the human the engine waits on for their answer, e.g. after a restart or a bots move.
+// Players without a hand view are asked to challenge in the channel, other prompts get the answer of a timed out prompt.
+func (g *Game) askWaiting(s *discordgo.Session) {
+	g.engineMux.Lock()
 	player := g.GetCurrentPlayer()
+	phase := g.Engine.Phase
+	if phase == engine.ChallengePhase {
+		player = g.GetNextPlayer()
+	}
+	g.engineMux.Unlock()
 
-	// Draw one card
-	card := DrawCards(g, 1)
-	player.Hand = append(player.Hand, card...)
-	player.LastDrawnCard = &card[0]
-	g.KeepCardData.User = i.Member.User.ID
+	if phase == engine.TurnPhase || phase == engine.OverPhase {
+		return
+	}
+	// Computer players answer on their own
+	if player == nil || player.IsBot() {
+		return
+	}
 
-	// Wait for players response to keep the card or play it.
-	keepCard := g.HandleDrawCard(s, i)
-	if !keepCard {
-		// Try to play card
-		g.PlayCard(s, i, player.LastDrawnCard.ID)
-	} else {
-		// Go to next turn
-		g.NextTurn()
-		// Force a render update after any interaction
-		g.RenderUpdate(s)
+	if player.Interaction == nil && phase != engine.ChallengePhase {
+		if err := g.applyAction(s, g.defaultAnswer()); err != nil {
+			log.Printf("Failed to answer for %s in game %s: %v", player.User.ID, g.ID, err)
+		}
+		g.update(s)
+		return
+	}
+
+	interaction := discordgo.Interaction{}
+	if player.Interaction != nil {
+		interaction = *player.Interaction
 	}
+	interaction.ChannelID = g.ChannelID
+	interaction.Member = &discordgo.Member{User: player.User}
+	i := &discordgo.InteractionCreate{Interaction: &interaction}
+	g.Apply(s, i, g.prompt(s, i))
 }
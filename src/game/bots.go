@@ -0,0 +1,192 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Ranzz02/uno-discord-bot/src/ai"
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/bwmarrin/discordgo"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+const (
+	// Pause before a computer player moves so people can follow along
+	BOT_DELAY time.Duration = 1500 * time.Millisecond
+)
+
+// Add a computer player to the lobby
+func (g *Game) AddBot(s *discordgo.Session, i *discordgo.InteractionCreate, values []string) {
+	if g.State != Lobby || g.Host != i.Member.User.ID {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "Only the host can add bots before the game starts.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	if len(g.Players) >= g.MaxPlayers {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("This game is full, %d players at most.", g.MaxPlayers),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	difficulty := ai.Easy
+	if len(values) > 0 {
+		level, err := strconv.Atoi(values[0])
+		if err == nil {
+			difficulty = ai.Difficulty(level)
+		}
+	}
+	g.NewBot(difficulty)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: g.RenderEmbed(s),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
+
+// Remove the last computer player from the lobby
+func (g *Game) RemoveBot(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if g.State != Lobby || g.Host != i.Member.User.ID {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "Only the host can remove bots before the game starts.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	for index := len(g.Players) - 1; index >= 0; index-- {
+		if g.Players[index].IsBot() {
+			g.RemovePlayer(s, g.Players[index].User.ID)
+			break
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: g.RenderEmbed(s),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
+
+// Add a computer player with the given difficulty
+func (g *Game) NewBot(difficulty ai.Difficulty) *Player {
+	id, _ := gonanoid.New()
+	count := 1
+	for _, player := range g.Players {
+		if player.IsBot() {
+			count++
+		}
+	}
+
+	user := &discordgo.User{
+		ID:       "bot-" + id,
+		Username: fmt.Sprintf("Bot %d (%s)", count, difficulty),
+		Bot:      true,
+	}
+
+	bot := g.NewPlayer(user, Normal, g.HandSize)
+	bot.Strategy = ai.New(difficulty)
+	bot.Difficulty = difficulty
+	return bot
+}
+
+// Let computer players act until it's up to a human
+func (g *Game) RunBots(s *discordgo.Session) {
+	g.botMux.Lock()
+	defer g.botMux.Unlock()
+
+	if g.botsRunning || g.State != Playing {
+		return
+	}
+	g.botsRunning = true
+
+	go func() {
+		for {
+			time.Sleep(BOT_DELAY)
+
+			g.botMux.Lock()
+			action := g.nextBotAction()
+			if action == nil || g.State != Playing {
+				g.botsRunning = false
+				// Nobody else asks the human after a bots Wild Draw Four
+				ask := action == nil && !g.asking && g.challengedByHuman()
+				if ask {
+					g.asking = true
+				}
+				g.botMux.Unlock()
+
+				if ask {
+					g.askWaiting(s)
+					g.botMux.Lock()
+					g.asking = false
+					g.botMux.Unlock()
+				}
+				return
+			}
+			g.botMux.Unlock()
+
+			if err := g.applyAction(s, action); err != nil {
+				log.Printf("Bot action %T rejected: %v", action, err)
+				g.botMux.Lock()
+				g.botsRunning = false
+				g.botMux.Unlock()
+				return
+			}
+			g.update(s)
+		}
+	}()
+}
+
+// Check if a computer player's Wild Draw Four waits on a human to challenge it
+func (g *Game) challengedByHuman() bool {
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+
+	if g.State != Playing || g.Engine.Phase != engine.ChallengePhase {
+		return false
+	}
+	current, next := g.GetCurrentPlayer(), g.GetNextPlayer()
+	return current != nil && current.IsBot() && next != nil && !next.IsBot()
+}
+
+// First thing any computer player wants to do
+func (g *Game) nextBotAction() engine.Action {
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+
+	for _, player := range g.Players {
+		if !player.IsBot() {
+			continue
+		}
+		if action := player.Strategy.Act(g.Engine, player.User.ID); action != nil {
+			return action
+		}
+	}
+
+	// Nothing to play, catch whoever forgot to call UNO
+	if g.Engine.Uncalled != "" {
+		for _, player := range g.Players {
+			if !player.IsBot() {
+				continue
+			}
+			if action := player.Strategy.Catch(g.Engine, player.User.ID); action != nil {
+				return action
+			}
+		}
+	}
+	return nil
+}
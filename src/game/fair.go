@@ -0,0 +1,131 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Commit to a server seed and the deck, games with a seed picked by the host can't be fair
+func (g *Game) commit() {
+	fair, err := engine.NewFairness(g.Engine.Rules, g.Engine.CustomDeck)
+	if err != nil {
+		return
+	}
+	g.Fair = fair
+}
+
+// Mix every join into the shuffle, Discord picks the interaction ID so the server can't steer it
+func (g *Game) mixJoin(user *discordgo.User, interactionID string) {
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+
+	if g.Fair == nil || g.State != Lobby {
+		return
+	}
+	g.Fair.Mix(user.ID + ":" + interactionID)
+}
+
+// Mix a seed the player typed into the shuffle, on top of the one from joining
+func (g *Game) AddSeed(s *discordgo.Session, i *discordgo.InteractionCreate, seed string) {
+	switch {
+	case g.Fair == nil:
+		replyEphemeral(s, i, "The host picked the seed of this game, there is nothing to mix into.")
+		return
+	case g.State != Lobby:
+		replyEphemeral(s, i, "Seeds can only be added before the game starts.")
+		return
+	case g.GetPlayer(i.Member.User.ID) == nil:
+		replyEphemeral(s, i, "Join the game before adding a seed.")
+		return
+	}
+
+	g.engineMux.Lock()
+	mixed := g.Fair.Mix(seed)
+	g.engineMux.Unlock()
+	g.RenderUpdate(s)
+
+	replyEphemeral(s, i, fmt.Sprintf("🎲 Your seed was mixed into the shuffle, the proof lists it as `%s`.", mixed))
+}
+
+// Helper function to render the commitment before the game starts
+func fairnessField(g *Game) *discordgo.MessageEmbedField {
+	return &discordgo.MessageEmbedField{
+		Name: "🔒 Provably fair shuffle",
+		Value: fmt.Sprintf("Commitment `%s`\nEvery join mixes a seed into the shuffle, add your own random text with `/uno seed` too, %d seeds so far. "+
+			"Note the commitment, the server seed is revealed when the game ends.", g.Fair.Commitment, len(g.Fair.PlayerSeeds)),
+		Inline: false,
+	}
+}
+
+// Helper function to render the revealed seeds after the game
+func proofField(g *Game) *discordgo.MessageEmbedField {
+	return &discordgo.MessageEmbedField{
+		Name:   "🔓 Fair shuffle proof",
+		Value:  fmt.Sprintf("```%s```Check it with `/uno verify` and the commitment shown while the game was played", g.Fair.Proof()),
+		Inline: false,
+	}
+}
+
+// Check a proof from an end screen against the commitment shown when the game started
+func Verify(s *discordgo.Session, i *discordgo.InteractionCreate, proof string, commitment string) {
+	result, err := engine.Verify(proof, commitment, engine.DefaultDeck, LoadSettings(i.GuildID).Deck)
+	if err != nil {
+		content := "That isn't a proof from an UNO end screen."
+		if errors.Is(err, engine.ErrUnknownDeck) {
+			content = "That proof was dealt from a custom deck this server doesn't use anymore."
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	title := "✅ Shuffle verified"
+	color := 0x00ff00
+	if !result.Committed {
+		title = "❌ Shuffle doesn't match"
+		color = 0xFF0000
+	}
+
+	check := func(ok bool) string {
+		if ok {
+			return "✅ matches"
+		}
+		return "❌ doesn't match"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       title,
+					Description: "The commitment was recomputed from the revealed server seed and the deck, and compared with the one shown before the game started.",
+					Color:       color,
+					Fields: []*discordgo.MessageEmbedField{
+						{
+							Name:  "Commitment",
+							Value: fmt.Sprintf("`%s`\n%s", result.Commitment, check(result.Committed)),
+						},
+						{
+							Name:  "First deck",
+							Value: fmt.Sprintf("`%s`\nCompare it with the first deck shown while the game was played", result.DeckHash),
+						},
+						{
+							Name:  "Seed",
+							Value: fmt.Sprintf("`%d`", result.Seed),
+						},
+					},
+				},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
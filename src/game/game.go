@@ -1,12 +1,17 @@
 package game
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/Ranzz02/uno-discord-bot/src/store"
 	"github.com/bwmarrin/discordgo"
 	gonanoid "github.com/matoous/go-nanoid/v2"
 )
@@ -17,8 +22,23 @@ const (
 	LeaveButton  string = "leave_button"
 	EndButton    string = "end_button"
 	ReplayButton string = "replay_button"
+	// House rules
+	RulesSelect       string = "rules_select"
+	StackingRule      string = "rule_stacking"
+	StackDrawFourRule string = "rule_stack_draw_four"
+	SevenORule        string = "rule_seven_o"
+	JumpInRule        string = "rule_jump_in"
+	PartnersRule      string = "rule_partners"
+	FlipRule          string = "rule_flip"
+	SwitchTeamButton  string = "switch_team_button"
+	ScoringSelect     string = "scoring_select"
+	TimerSelect       string = "timer_select"
+	// Computer players
+	AddBotSelect    string = "add_bot_select"
+	RemoveBotOption string = "remove"
 	// Playing
 	UNOButton           string = "uno_button"
+	CatchButton         string = "catch_button"
 	ViewCardsButton     string = "view_cards_button"
 	DrawCardAction      string = "draw-card"
 	KeepCardAction      string = "keep-card"
@@ -26,6 +46,16 @@ const (
 	// Challenge buttons
 	ChallengeButton       string = "challenge_button"
 	ChallengeIgnoreButton string = "challenge_ignore"
+	// Seven-O swap select menu
+	SwapSelect string = "swap_select"
+	// /uno start options
+	SeedOption       string = "seed"
+	HandSizeOption   string = "hand_size"
+	RulesOption      string = "rules"
+	TurnTimerOption  string = "turn_timer"
+	MaxPlayersOption string = "max_players"
+	ScoringOption    string = "scoring"
+	PrivateOption    string = "private"
 	// Pagination buttons
 	PreviousButton string = "previous_button"
 	NextButton     string = "next_button"
@@ -33,41 +63,107 @@ const (
 
 const (
 	MAX_CARDS_PER_PAGE int = 15
+	// Time a player has to call UNO before they can be caught
+	UNO_WINDOW time.Duration = 5 * time.Second
+	// Official score to win a match
+	DEFAULT_TARGET_SCORE int = 500
+	// Time a player has for their turn before a card is drawn for them
+	DEFAULT_TURN_TIMEOUT time.Duration = 60 * time.Second
+	// Timeouts in a row before a player is removed, 0 never removes them
+	DEFAULT_MAX_TIMEOUTS int = 3
+	// Largest seed a Discord integer option can hold
+	MAX_SEED int64 = 1 << 53
+	// Cards dealt to every player
+	DEFAULT_HAND_SIZE int = 7
+	// Official rules play with 2 to 10 players
+	DEFAULT_MAX_PLAYERS int = 10
+	// Time a player has to answer a prompt before the default is picked for them
+	DEFAULT_COLOR_TIMEOUT     time.Duration = 30 * time.Second
+	DEFAULT_CHALLENGE_TIMEOUT time.Duration = 30 * time.Second
+	DEFAULT_KEEP_TIMEOUT      time.Duration = 10 * time.Second
 )
 
+// Match targets the host can pick from, 0 plays a single round
+var TargetScores = []int{0, 100, 250, DEFAULT_TARGET_SCORE, 1000}
+
+// AFK limits server managers can pick from
+var MaxTimeoutChoices = []int{0, 1, 2, DEFAULT_MAX_TIMEOUTS, 5}
+
+// Turn timers the host can pick from, 0 turns the timer off
+var TurnTimeouts = []time.Duration{0, 30 * time.Second, DEFAULT_TURN_TIMEOUT, 2 * time.Minute, 5 * time.Minute}
+
+// House rules by the name typed in the rules option, "official" turns them all off
+var RuleNames = map[string]string{
+	"stacking":              StackingRule,
+	"draw-four-on-draw-two": StackDrawFourRule,
+	"seven-o":               SevenORule,
+	"jump-in":               JumpInRule,
+	"partners":              PartnersRule,
+	"flip":                  FlipRule,
+}
+
 var (
 	games    = map[string]*Game{}
 	gamesMux = sync.Mutex{}
 )
 
+var ErrGameEnded = errors.New("the game was ended")
+
 type GameState int
 
 const (
 	Lobby GameState = iota
 	Playing
 	EndScreen
+	// Watching a replay file, not a real game
+	Replaying
+	// Deleted before anyone won, nothing may touch it anymore
+	Ended
 )
 
 type Game struct {
 	ID            string
-	Deck          []Card
-	DiscardPile   []Card
+	ChannelID     string
+	GuildID       string // Stats are kept per guild
+	Engine        *engine.State
 	Players       []*Player
-	CurrentTurn   int
-	Reversed      bool
-	UNO           bool
+	UnoDeadline   time.Time
 	State         GameState
 	Host          string
 	Interaction   *discordgo.Interaction
+	MessageID     string // Game message posted after a restart, edited instead of the interaction response
 	ColorData     ColorData
 	ChallengeData ChallengeData
 	KeepCardData  KeepCardData
+	SwapData      SwapData
 	Winner        *Player
+	TargetScore   int // Score needed to win the match, 0 plays a single round
+	Scores        map[string]int
+	Round         int
+	TurnTimeout   time.Duration // 0 turns the timer off
+	MaxTimeouts   int
+	TurnData      TurnData
+	HandSize      int
+	MaxPlayers    int
+	// Prompt timers, the swap prompt uses the color timer
+	ColorTimeout     time.Duration
+	ChallengeTimeout time.Duration
+	KeepTimeout      time.Duration
+	Fair             *engine.Fairness // Nil when the host picked the seed
+	FairDeckHash     string           // Hash of the first deck dealt from the fair seed
+	Replay           *engine.Replay   // Recorded from the first deal
+	JoinCode         string           // Empty for public lobbies
+	engineMux        sync.Mutex
+	timerMux         sync.Mutex
+	botMux           sync.Mutex
+	botsRunning      bool
+	asking           bool       // A human is being asked to challenge a bots Wild Draw Four
+	Log              []LogEntry // Append only, see record
+	logMux           sync.Mutex
 }
 
 type ColorData struct {
 	ColorResponse chan string
-	CurrentColor  *string
 	User          string
 }
 
@@ -81,23 +177,118 @@ type KeepCardData struct {
 	User         string
 }
 
-// Start a new game
-func NewGame(i *discordgo.InteractionCreate) *Game {
+type SwapData struct {
+	SwapResponse chan string
+	User         string
+}
+
+// Start a new game with the guilds settings, changed by the hosts options
+func NewGame(i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) (*Game, error) {
+	settings := LoadSettings(i.GuildID)
+	if !settings.Allows(i.ChannelID) {
+		return nil, ErrChannelNotAllowed
+	}
+
+	// Host can pick the seed to replay a game exactly
+	seed := rand.Int63n(MAX_SEED)
+	fixed := false
+	targetScore := DEFAULT_TARGET_SCORE
+	private := false
+	for _, option := range options {
+		switch option.Name {
+		case SeedOption:
+			seed = option.IntValue()
+			fixed = true
+		case HandSizeOption:
+			settings.HandSize = int(option.IntValue())
+		case MaxPlayersOption:
+			settings.MaxPlayers = int(option.IntValue())
+		case TurnTimerOption:
+			settings.TurnTimeout = time.Duration(option.IntValue()) * time.Second
+		case ScoringOption:
+			targetScore = int(option.IntValue())
+		case PrivateOption:
+			private = option.BoolValue()
+		case RulesOption:
+			rules, err := namedRules(option.StringValue())
+			if err != nil {
+				return nil, err
+			}
+			settings.Rules = rules
+		}
+	}
+	if err := validateLimits(settings.HandSize, settings.MaxPlayers, settings.Deck); err != nil {
+		return nil, err
+	}
+
+	game := newLobby(i.ChannelID, i.Interaction, i.Member.User.ID, seed)
+	if game == nil {
+		return nil, ErrCreateLobby
+	}
+	game.GuildID = i.GuildID
+	game.applySettings(settings)
+	game.TargetScore = targetScore
+	if private {
+		game.lock()
+	}
+	if !fixed {
+		game.commit()
+	}
+
+	// Add host to game
+	game.NewPlayer(i.Member.User, Host, game.HandSize)
+	game.mixJoin(i.Member.User, i.ID)
+
+	return game, nil
+}
+
+// House rules from a comma separated list of rule names
+func namedRules(list string) (engine.Rules, error) {
+	var values []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "official" {
+			continue
+		}
+
+		value, ok := RuleNames[name]
+		if !ok {
+			names := make([]string, 0, len(RuleNames))
+			for known := range RuleNames {
+				names = append(names, known)
+			}
+			sort.Strings(names)
+			return engine.Rules{}, fmt.Errorf("unknown house rule %q, pick from official, %s", name, strings.Join(names, ", "))
+		}
+		values = append(values, value)
+	}
+	return parseRules(values), nil
+}
+
+// Create an empty lobby and register it as the channels game
+func newLobby(channelID string, interaction *discordgo.Interaction, host string, seed int64) *Game {
 	id, err := gonanoid.New()
 	if err != nil {
 		return nil
 	}
 
 	game := &Game{
-		ID:          id,
-		Deck:        GenerateDeck(),
-		DiscardPile: []Card{},
-		CurrentTurn: 0,
-		Reversed:    false,
-		State:       Lobby,
-		UNO:         false,
-		Host:        i.Member.User.ID,
-		Interaction: i.Interaction,
+		ID:               id,
+		ChannelID:        channelID,
+		Engine:           engine.NewWithRules(engine.Rules{}, seed),
+		State:            Lobby,
+		Host:             host,
+		Interaction:      interaction,
+		TargetScore:      DEFAULT_TARGET_SCORE,
+		Scores:           map[string]int{},
+		Round:            1,
+		TurnTimeout:      DEFAULT_TURN_TIMEOUT,
+		MaxTimeouts:      DEFAULT_MAX_TIMEOUTS,
+		HandSize:         DEFAULT_HAND_SIZE,
+		MaxPlayers:       DEFAULT_MAX_PLAYERS,
+		ColorTimeout:     DEFAULT_COLOR_TIMEOUT,
+		ChallengeTimeout: DEFAULT_CHALLENGE_TIMEOUT,
+		KeepTimeout:      DEFAULT_KEEP_TIMEOUT,
 		ColorData: ColorData{
 			ColorResponse: make(chan string, 5),
 		},
@@ -107,31 +298,13 @@ func NewGame(i *discordgo.InteractionCreate) *Game {
 		KeepCardData: KeepCardData{
 			KeepResponse: make(chan bool, 1),
 		},
+		SwapData: SwapData{
+			SwapResponse: make(chan string, 1),
+		},
 	}
 
-	// Shuffle deck
-	ShuffleDeck(game.Deck)
-	game.TopCard()
-
-	// Select the first card and validate it's not a Wild or Wild Draw Four
-	firstCard := game.Deck[0] // Get the first card from the shuffled deck
-
-	// Ensure the first card isn't a Wild or Wild Draw Four card
-	for firstCard.Type != NumberCard {
-		// If it's a Wild or Wild Draw Four, reshuffle the deck and pick a new first card
-		ShuffleDeck(game.Deck)
-		firstCard = game.Deck[0]
-	}
-
-	// Place the first valid card on the discard pile
-	game.DiscardPile = append(game.DiscardPile, firstCard)
-	game.Deck = game.Deck[1:] // Remove the first card from the deck
-
-	// Add host to game
-	game.NewPlayer(i.Member.User, Host, 7)
-
 	gamesMux.Lock()
-	games[i.ChannelID] = game
+	games[channelID] = game
 	gamesMux.Unlock()
 
 	return game
@@ -141,21 +314,87 @@ func NewGame(i *discordgo.InteractionCreate) *Game {
 func (g *Game) EndGame(s *discordgo.Session, player *Player) {
 	g.State = EndScreen
 	g.Winner = player
+	g.StopTurnTimer()
 
 	// Delete view hands
 	for _, player := range g.Players {
 		if player.Interaction != nil {
 			s.InteractionResponseDelete(player.Interaction)
+			player.Interaction = nil
 		}
 	}
 
-	// Remove game from games
-	gamesMux.Lock()
-	defer gamesMux.Unlock()
-	delete(games, g.ID)
+	// Game stays registered so the end screen can start a rematch,
+	// the next game in the channel replaces it.
 
 	// Update UI
 	g.RenderUpdate(s)
+	g.attachReplay(s)
+	summaries := g.summarize()
+	g.recordStats(summaries)
+	g.announceUnlocks(s, g.unlockAchievements(summaries))
+}
+
+// Score the round and deal the next one, or end the game
+func (g *Game) EndRound(s *discordgo.Session) {
+	winner := g.GetPlayer(g.Engine.Winner.ID)
+	if g.TargetScore <= 0 {
+		g.EndGame(s, winner)
+		return
+	}
+
+	// Scores are saved by the autosave
+	g.engineMux.Lock()
+	points := g.Engine.RoundPoints()
+	g.Scores[g.scoreKey(winner)] += points
+	score := g.Scores[g.scoreKey(winner)]
+	g.engineMux.Unlock()
+	if score >= g.TargetScore {
+		g.EndGame(s, winner)
+		return
+	}
+
+	// Fresh deck, the first turn moves one seat each round
+	g.engineMux.Lock()
+	g.Engine.Reset(g.HandSize, g.Round)
+	g.recordStep(engine.Step{Kind: engine.RoundStep, Turn: g.Round})
+	g.engineMux.Unlock()
+
+	g.record(fmt.Sprintf("🏁 %s won round %d and scored **%d** points!", teamName(g, winner), g.Round, points))
+	g.Round++
+	g.recordDeal(g.HandSize)
+	for _, player := range g.Players {
+		player.Page = 0
+	}
+	g.StartTurnTimer(s)
+
+	g.RenderUpdate(s)
+}
+
+// Stop the game for good, bots, timers and renders that are still running give up
+func (g *Game) end() {
+	g.engineMux.Lock()
+	g.State = Ended
+	g.engineMux.Unlock()
+
+	g.StopTurnTimer()
+	g.unregister()
+}
+
+// Remove game from games, unless the channel already moved on to a new one
+func (g *Game) unregister() {
+	gamesMux.Lock()
+	defer gamesMux.Unlock()
+
+	if games[g.ChannelID] == g {
+		delete(games, g.ChannelID)
+		store.Delete(GamesBucket, g.ChannelID)
+	}
+}
+
+// Check if a button belongs to a message that isn't the channels game
+func IsStandaloneButton(customID string) bool {
+	return IsReplayButton(customID) || IsLeaderboardButton(customID) || IsSettingsComponent(customID) || IsHelpComponent(customID)
 }
 
 // Find a game
@@ -170,192 +409,192 @@ func FindGame(gameID string) *Game {
 	return g
 }
 
-func (g *Game) TopCard() Card {
-	// Check if the discard pile is empty
-	if len(g.DiscardPile) == 0 {
-		// If the discard pile is empty, draw the first card from the deck
-		firstCard := g.Deck[0]
-
-		// Ensure the first card isn't a Wild or Wild Draw Four card
-		for firstCard.Type == WildCard || firstCard.Type == WildDrawFourCard {
-			// If it's a Wild or Wild Draw Four, reshuffle the deck and pick a new first card
-			ShuffleDeck(g.Deck)
-			firstCard = g.Deck[0]
-		}
-
-		// Place the valid card on the discard pile and remove it from the deck
-		g.DiscardPile = append(g.DiscardPile, firstCard)
-		g.Deck = g.Deck[1:] // Remove the first card from the deck
-
-		return firstCard
-	}
-
-	return g.DiscardPile[len(g.DiscardPile)-1]
+func (g *Game) TopCard() engine.Card {
+	return g.Engine.TopCard()
 }
 
 // Function to check if card can be played
-func (g *Game) CanPlayCard(card *Card) bool {
-	topCard := g.TopCard()
-
-	if card.Type == WildCard || card.Type == WildDrawFourCard {
-		return true
-	}
-
-	// Split the card name to get color and type (number)
-	cardDetails := strings.Split(card.Name, "-")
-	cardColor := cardDetails[0]
-	cardType := cardDetails[1]
+func (g *Game) CanPlayCard(card *engine.Card) bool {
+	return g.Engine.CanPlay(*card)
+}
 
-	// Split the top card to get the color and type
-	topCardDetails := strings.Split(topCard.Name, "-")
-	topCardColor := topCardDetails[0]
-	topCardType := topCardDetails[1]
+// Change the current color for Wild and WildDrawFour cards
+func (g *Game) ChangeColor(s *discordgo.Session, i *discordgo.InteractionCreate) string {
+	// Send an ephemeral message asking the player to select a color
+	colorPrompt := "Please select a color for the Wild card!"
 
-	// If wild card
-	if topCardColor == "wild" && g.ColorData.CurrentColor != nil && *g.ColorData.CurrentColor == cardColor {
-		return true
+	embeds := []*discordgo.MessageEmbed{
+		{
+			Title:       "Select color",
+			Description: colorPrompt,
+		},
+	}
+	// Colors of the side that is up
+	colors := g.Engine.ActiveColors()
+	var buttons []discordgo.MessageComponent
+	for _, color := range colors {
+		buttons = append(buttons, &discordgo.Button{
+			Label:    colorEmoji(color) + " " + strings.ToUpper(color[:1]) + color[1:],
+			Style:    discordgo.SecondaryButton,
+			CustomID: "color_" + color,
+		})
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: buttons,
+		},
 	}
 
-	// Check if the card can be played (must match either color or type)
-	if cardColor == topCardColor || cardType == topCardType {
-		return true
+	err := promptPlayer(s, i, embeds, components)
+	if err != nil {
+		log.Printf("Error sending color selection message: %v", err)
+		return colors[0]
 	}
 
-	return false
+	// Wait for the user to react with one of the color emojis
+	return g.WaitForColorSelection(s, i)
 }
 
-// Function to check if card can be played
-func (g *Game) CanPlayPreviousCard(card *Card) bool {
-	topCard := g.DiscardPile[len(g.DiscardPile)-2]
+func (g *Game) WaitForColorSelection(s *discordgo.Session, i *discordgo.InteractionCreate) string {
+	g.ColorData.User = i.Member.User.ID
 
-	if card.Type == WildCard || card.Type == WildDrawFourCard {
-		return true
+	select {
+	case selectedColor := <-g.ColorData.ColorResponse:
+		return selectedColor
+	case <-time.After(g.ColorTimeout):
+		return g.Engine.ActiveColors()[0]
 	}
+}
 
-	// Split the card name to get color and type (number)
-	cardDetails := strings.Split(card.Name, "-")
-	cardColor := cardDetails[0]
-	cardType := cardDetails[1]
-
-	// Split the top card to get the color and type
-	topCardDetails := strings.Split(topCard.Name, "-")
-	topCardColor := topCardDetails[0]
-	topCardType := topCardDetails[1]
+// Let the player pick someone to swap hands with after a 7
+func (g *Game) ChooseSwap(s *discordgo.Session, i *discordgo.InteractionCreate) string {
+	current := g.GetCurrentPlayer()
 
-	// If wild card
-	if topCardColor == "wild" && g.ColorData.CurrentColor != nil && *g.ColorData.CurrentColor == cardColor {
-		return true
+	var options []discordgo.SelectMenuOption
+	for _, player := range g.Players {
+		if player == current {
+			continue
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       player.User.Username,
+			Value:       player.User.ID,
+			Description: fmt.Sprintf("%d cards", len(player.Hand)),
+		})
 	}
 
-	// Check if the card can be played (must match either color or type)
-	if cardColor == topCardColor || cardType == topCardType {
-		return true
+	embeds := []*discordgo.MessageEmbed{
+		{
+			Title:       "Swap hands",
+			Description: "You played a 7! Pick a player to swap hands with.",
+		},
 	}
-
-	return false
-}
-
-// Change the current color for Wild and WildDrawFour cards
-func (g *Game) ChangeColor(s *discordgo.Session, i *discordgo.InteractionCreate) string {
-	// Send an ephemeral message asking the player to select a color
-	colorPrompt := "Please select a color for the Wild card!"
-
-	// Send the message to the player, this will be ephemeral (only visible to the player)
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Data: &discordgo.InteractionResponseData{
-			Embeds: []*discordgo.MessageEmbed{
-				{
-					Title:       "Select color",
-					Description: colorPrompt,
-				},
-			},
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						&discordgo.Button{
-							Label:    "🟥 Red",
-							Style:    discordgo.SecondaryButton,
-							CustomID: "color_red",
-						},
-						&discordgo.Button{
-							Label:    "🟩 Green",
-							Style:    discordgo.SecondaryButton,
-							CustomID: "color_green",
-						},
-						&discordgo.Button{
-							Label:    "🟦 Blue",
-							Style:    discordgo.SecondaryButton,
-							CustomID: "color_blue",
-						},
-						&discordgo.Button{
-							Label:    "🟨 Yellow",
-							Style:    discordgo.SecondaryButton,
-							CustomID: "color_yellow",
-						},
-					},
+				discordgo.SelectMenu{
+					CustomID:    SwapSelect,
+					Placeholder: "Choose a player",
+					Options:     options,
 				},
 			},
-			Flags: discordgo.MessageFlagsEphemeral,
 		},
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-	})
+	}
+
+	err := promptPlayer(s, i, embeds, components)
 	if err != nil {
-		log.Printf("Error sending color selection message: %v", err)
-		return ""
+		log.Printf("Error sending swap selection message: %v", err)
+		return g.GetNextPlayer().User.ID
 	}
 
-	// Wait for the user to react with one of the color emojis
-	selectedColor := g.WaitForColorSelection(s, i)
-	g.ColorData.CurrentColor = &selectedColor
-	return selectedColor
+	return g.WaitForSwapSelection(s, i)
 }
 
-func (g *Game) WaitForColorSelection(s *discordgo.Session, i *discordgo.InteractionCreate) string {
-	g.ColorData.User = i.Member.User.ID
+// Wait for response or swap with the next player when the color timer runs out
+func (g *Game) WaitForSwapSelection(s *discordgo.Session, i *discordgo.InteractionCreate) string {
+	g.SwapData.User = i.Member.User.ID
 
 	select {
-	case selectedColor := <-g.ColorData.ColorResponse:
-		return selectedColor
-	case <-time.After(30 * time.Second):
-		return "red"
+	case target := <-g.SwapData.SwapResponse:
+		return target
+	case <-time.After(g.ColorTimeout):
+		return g.GetNextPlayer().User.ID
+	}
+}
+
+// Send an ephemeral prompt, as a follow up if the interaction was already answered
+func promptPlayer(s *discordgo.Session, i *discordgo.InteractionCreate, embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent) error {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     embeds,
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+	if err != nil {
+		// Interaction was already answered, e.g. when playing a drawn card
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Embeds:     embeds,
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		})
 	}
+	return err
 }
 
 func (g *Game) ChallengeChoice(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
 	nextPlayer := g.GetNextPlayer()
 	g.ChallengeData.User = nextPlayer.User.ID
 
-	// Send the message to the player, this will be ephemeral (only visible to the player)
-	_, err := s.InteractionResponseEdit(nextPlayer.Interaction, &discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{
-			{
-				Title:       "Challenge wild draw four!",
-				Description: "Do you want to challenge the Wild Draw Four?",
-			},
+	embeds := []*discordgo.MessageEmbed{
+		{
+			Title:       "Challenge the wild draw card!",
+			Description: fmt.Sprintf("Do you want to challenge the **%s**?", g.TopCard().Name),
 		},
-		Components: &[]discordgo.MessageComponent{
-			discordgo.ActionsRow{
-				Components: []discordgo.MessageComponent{
-					&discordgo.Button{
-						Label:    "Challenge",
-						Style:    discordgo.DangerButton,
-						CustomID: ChallengeButton,
-					},
-					&discordgo.Button{
-						Label:    "Ignore",
-						Style:    discordgo.SecondaryButton,
-						CustomID: ChallengeIgnoreButton,
-					},
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				&discordgo.Button{
+					Label:    "Challenge",
+					Style:    discordgo.DangerButton,
+					CustomID: ChallengeButton,
+				},
+				&discordgo.Button{
+					Label:    "Ignore",
+					Style:    discordgo.SecondaryButton,
+					CustomID: ChallengeIgnoreButton,
 				},
 			},
 		},
+	}
+
+	// Player has no hand view to ask in, ask in the channel instead. Only they can answer.
+	if nextPlayer.Interaction == nil {
+		message, err := s.ChannelMessageSendComplex(g.ChannelID, &discordgo.MessageSend{
+			Content:    g.Mention(nextPlayer.User.ID),
+			Embeds:     embeds,
+			Components: components,
+		})
+		if err != nil {
+			log.Printf("Error sending challenge message: %v", err)
+			return false
+		}
+		defer s.ChannelMessageDelete(g.ChannelID, message.ID)
+		return g.WaitForChallengeSelection(s, i)
+	}
+
+	// Send the message to the player, this will be ephemeral (only visible to the player)
+	_, err := s.InteractionResponseEdit(nextPlayer.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &embeds,
+		Components: &components,
 	})
 	if err != nil {
-		log.Printf("Error sending color selection message: %v", err)
+		log.Printf("Error sending challenge message: %v", err)
 		return false
 	}
 
-	// Wait for the user to react with one of the color emojis
+	// Wait for the player to challenge or ignore
 	return g.WaitForChallengeSelection(s, i)
 }
 
@@ -365,17 +604,17 @@ func (g *Game) WaitForChallengeSelection(s *discordgo.Session, i *discordgo.Inte
 	case selectedChoice := <-g.ChallengeData.ChallengeResponse:
 		log.Printf("Challenge choice made: %v", selectedChoice)
 		return selectedChoice // Player responded
-	case <-time.After(30 * time.Second):
+	case <-time.After(g.ChallengeTimeout):
 		return false // Timeout occurred, default choice (false)
 	}
 }
 
 func (g *Game) HandleDrawCard(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
-	player := g.GetCurrentPlayer()
+	drawn := g.Engine.Drawn
 
 	// Determine the color of the embed based on the card
 	var embedColor int
-	switch strings.Split(player.LastDrawnCard.Name, "-")[0] {
+	switch drawn.Color {
 	case "red":
 		embedColor = 0xFF0000
 	case "blue":
@@ -384,18 +623,24 @@ func (g *Game) HandleDrawCard(s *discordgo.Session, i *discordgo.InteractionCrea
 		embedColor = 0x00FF00
 	case "yellow":
 		embedColor = 0xFFFF00
+	case "pink":
+		embedColor = 0xFF69B4
+	case "teal":
+		embedColor = 0x008080
+	case "orange":
+		embedColor = 0xFFA500
+	case "purple":
+		embedColor = 0x800080
 	default:
 		embedColor = 0xFFFFFF // White for wild cards
 	}
 
 	// Create an embed showing the drawn card
 	embed := discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("You drew a **%s**!", player.LastDrawnCard.Name),
+		Title:       fmt.Sprintf("You drew a **%s**!", drawn.Name),
 		Description: "Do you want to play it or keep it?",
 		Color:       embedColor,
-		Image: &discordgo.MessageEmbedImage{
-			URL: player.LastDrawnCard.Link,
-		},
+		Image:       cardImage(*drawn),
 	}
 
 	// Ask the player to decide
@@ -409,7 +654,7 @@ func (g *Game) HandleDrawCard(s *discordgo.Session, i *discordgo.InteractionCrea
 							Label:    "Play card",
 							Style:    discordgo.SuccessButton,
 							CustomID: PlayDrawnCardAction,
-							Disabled: !g.CanPlayCard(player.LastDrawnCard),
+							Disabled: !g.CanPlayCard(drawn),
 						},
 						&discordgo.Button{
 							Label:    "Keep",
@@ -424,18 +669,18 @@ func (g *Game) HandleDrawCard(s *discordgo.Session, i *discordgo.InteractionCrea
 	})
 	if err != nil {
 		log.Printf("Failed to ask to keep card: %v", err)
-		return false
+		return true
 	}
 
 	return g.WaitForKeepCard(s, i)
 }
 
-// Wait for response or timeout after 10 seconds
+// Wait for response or keep the card when the keep timer runs out
 func (g *Game) WaitForKeepCard(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
 	select {
 	case selectedChoice := <-g.KeepCardData.KeepResponse:
 		return selectedChoice
-	case <-time.After(10 * time.Second):
+	case <-time.After(g.KeepTimeout):
 		return true
 	}
 }
@@ -0,0 +1,135 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Ranzz02/uno-discord-bot/src/ai"
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Lobby without timers, ended when the test is over
+func testGame(t *testing.T, channelID string) *Game {
+	g := newLobby(channelID, nil, "host", 1)
+	if g == nil {
+		t.Fatal("no lobby")
+	}
+	g.TurnTimeout = 0
+	g.commit()
+	g.NewPlayer(&discordgo.User{ID: "host", Username: "host"}, Host, g.HandSize)
+	t.Cleanup(g.end)
+	return g
+}
+
+// Players join, switch teams and get rendered while the autosave runs
+func TestConcurrentLobby(t *testing.T) {
+	g := testGame(t, "lobby")
+	g.Engine.Rules.Partners = true
+	g.assignTeams()
+
+	var wg sync.WaitGroup
+	for index := 0; index < 7; index++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			user := &discordgo.User{ID: fmt.Sprintf("p%d", index), Username: fmt.Sprintf("p%d", index)}
+			g.NewPlayer(user, Normal, g.HandSize)
+			g.mixJoin(user, fmt.Sprintf("join%d", index))
+		}()
+		go func() {
+			defer wg.Done()
+			g.RenderEmbed(nil)
+		}()
+		go func() {
+			defer wg.Done()
+			g.save()
+		}()
+	}
+	wg.Wait()
+
+	if len(g.Players) != 8 || len(g.Engine.Players) != 8 {
+		t.Fatalf("%d players, %d seats, want 8", len(g.Players), len(g.Engine.Players))
+	}
+	if got := len(g.Fair.PlayerSeeds); got != 7 {
+		t.Errorf("%d seeds mixed in, want 7", got)
+	}
+	if err := g.arrangeTeams(); err != nil {
+		t.Errorf("arrange teams: %v", err)
+	}
+}
+
+// Bots race each other for every move while the game is rendered and saved
+func TestConcurrentTurns(t *testing.T) {
+	g := testGame(t, "turns")
+	// The host sits in for a human who plays like a bot
+	g.Players[0].Strategy = ai.New(ai.Easy)
+	for index := 0; index < 3; index++ {
+		g.NewBot(ai.Hard)
+	}
+	g.deal()
+	g.State = Playing
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for move := 0; move < 200; move++ {
+				action := g.nextBotAction()
+				if action == nil {
+					return
+				}
+				// Losers of a race are rejected
+				g.applyAction(nil, action)
+			}
+		}()
+	}
+	for worker := 0; worker < 2; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for render := 0; render < 50; render++ {
+				g.RenderEmbed(nil)
+				g.RenderPlayerHand("host")
+				g.save()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every accepted move was recorded, replaying them ends up in the same spot
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+	replayed, _, err := g.Replay.Seek(len(g.Replay.Steps))
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if replayed.CurrentTurn != g.Engine.CurrentTurn || replayed.Phase != g.Engine.Phase || len(replayed.Deck) != len(g.Engine.Deck) {
+		t.Error("replay of the recorded moves differs from the game")
+	}
+	if len(g.Replay.Steps) < 10 && g.Engine.Phase != engine.OverPhase {
+		t.Errorf("%d moves before the bots stopped, want a finished round or 10 moves", len(g.Replay.Steps))
+	}
+}
+
+// Paging stops at the last page of the hand, not after MAX_CARDS_PER_PAGE pages
+func TestTurnPage(t *testing.T) {
+	g := testGame(t, "pages")
+	host := g.GetPlayer("host")
+	host.Hand = make([]engine.Card, MAX_CARDS_PER_PAGE+1)
+
+	if g.TurnPage("host", -1) {
+		t.Error("turned before the first page")
+	}
+	if !g.TurnPage("host", 1) || host.Page != 1 {
+		t.Fatalf("page %d, want 1", host.Page)
+	}
+	if g.TurnPage("host", 1) {
+		t.Error("turned past the last page")
+	}
+	if g.TurnPage("nobody", 1) {
+		t.Error("turned the page of a missing player")
+	}
+}
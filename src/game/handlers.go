@@ -1,18 +1,68 @@
 package game
 
 import (
+	"fmt"
 	"log"
+	"math/rand"
+	"strconv"
 	"time"
 
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
 	"github.com/bwmarrin/discordgo"
 )
 
-// Add player to the game
+// Add player to the game, private lobbies ask for the join code first
 func (g *Game) AddPlayer(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if reason := g.joinError(i.Member.User.ID); reason != "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: reason,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	if g.JoinCode != "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: joinCodeModal(),
+			Type: discordgo.InteractionResponseModal,
+		})
+		return
+	}
+
+	g.NewPlayer(i.Member.User, Normal, g.HandSize)
+	g.mixJoin(i.Member.User, i.ID)
+
+	// Respond with the updated embed, rendering the correct buttons
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: g.RenderEmbed(s),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+
+}
+
+// Why the user can't join, empty if they can
+func (g *Game) joinError(userID string) string {
 	if g.State != Lobby {
+		return "This game started already."
+	}
+	if g.GetPlayer(userID) != nil {
+		return "You are already in the game."
+	}
+	if len(g.Players) >= g.MaxPlayers {
+		return fmt.Sprintf("This game is full, %d players at most.", g.MaxPlayers)
+	}
+	return ""
+}
+
+// Player leaves the game
+func (g *Game) LeaveGame(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if g.State == EndScreen || g.GetPlayer(i.Member.User.ID) == nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Data: &discordgo.InteractionResponseData{
-				Content: "This game started already.",
+				Content: "You are not in this game.",
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -20,18 +70,32 @@ func (g *Game) AddPlayer(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	exists := false
-	for _, player := range g.Players {
-		if player.User.ID == i.Member.User.ID {
-			exists = true
-			break
-		}
+	g.RemovePlayer(s, i.Member.User.ID)
+
+	// Last player left, nothing to show anymore
+	if g.Humans() == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{everyoneLeft()},
+				Components: []discordgo.MessageComponent{},
+			},
+			Type: discordgo.InteractionResponseUpdateMessage,
+		})
+		return
 	}
 
-	if exists {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: g.RenderEmbed(s),
+	})
+}
+
+// Pick the house rules from the lobby
+func (g *Game) SetRules(s *discordgo.Session, i *discordgo.InteractionCreate, values []string) {
+	if g.State != Lobby || g.Host != i.Member.User.ID {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Data: &discordgo.InteractionResponseData{
-				Content: "You are already in the game.",
+				Content: "Only the host can change rules before the game starts.",
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -39,22 +103,53 @@ func (g *Game) AddPlayer(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	g.NewPlayer(i.Member.User, Normal, 7)
+	g.engineMux.Lock()
+	previous := g.Engine.Rules
+	g.Engine.Rules = parseRules(values)
+	rules := g.Engine.Rules
+
+	if rules.Partners && !previous.Partners {
+		g.assignTeams()
+	}
+	// Everyone gets a new hand from the other deck, the commitment has to name it
+	if rules.Flip != previous.Flip {
+		g.Engine.Reset(g.HandSize, 0)
+		if g.Fair != nil {
+			g.Fair.Commit(rules, g.Engine.CustomDeck)
+		}
+	}
+	g.engineMux.Unlock()
 
-	// Respond with the updated embed, rendering the correct buttons
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Data: g.RenderEmbed(s),
 		Type: discordgo.InteractionResponseUpdateMessage,
 	})
+}
+
+// House rules from the values picked in a rules select
+func parseRules(values []string) engine.Rules {
+	enabled := map[string]bool{}
+	for _, value := range values {
+		enabled[value] = true
+	}
 
+	return engine.Rules{
+		// Wild Draw Four on Draw Two only makes sense with stacking
+		Stacking:               enabled[StackingRule] || enabled[StackDrawFourRule],
+		StackDrawFourOnDrawTwo: enabled[StackDrawFourRule],
+		SevenO:                 enabled[SevenORule],
+		JumpIn:                 enabled[JumpInRule],
+		Partners:               enabled[PartnersRule],
+		Flip:                   enabled[FlipRule],
+	}
 }
 
-// Player leaves the game
-func (g *Game) LeaveGame(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if g.State != Lobby {
+// Pick the score needed to win the match from the lobby
+func (g *Game) SetTargetScore(s *discordgo.Session, i *discordgo.InteractionCreate, values []string) {
+	if g.State != Lobby || g.Host != i.Member.User.ID {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Data: &discordgo.InteractionResponseData{
-				Content: "This game started already. Wait till it ends",
+				Content: "Only the host can change rules before the game starts.",
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -62,29 +157,140 @@ func (g *Game) LeaveGame(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
+	if len(values) > 0 {
+		target, err := strconv.Atoi(values[0])
+		if err == nil && target >= 0 {
+			g.TargetScore = target
+		}
+	}
+
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: g.RenderEmbed(s),
 		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
+
+// Pick the turn timer from the lobby
+func (g *Game) SetTurnTimeout(s *discordgo.Session, i *discordgo.InteractionCreate, values []string) {
+	if g.State != Lobby || g.Host != i.Member.User.ID {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "Only the host can change rules before the game starts.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	if len(values) > 0 {
+		seconds, err := strconv.Atoi(values[0])
+		if err == nil && seconds >= 0 {
+			g.TurnTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Data: g.RenderEmbed(s),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
+
+// Start a new lobby with the same players, host and rules
+func (g *Game) Rematch(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if g.State != EndScreen || g.GetPlayer(i.Member.User.ID) == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "Only players from this game can start a rematch.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	rematch := newLobby(g.ChannelID, i.Interaction, g.Host, rand.Int63n(MAX_SEED))
+	if rematch == nil {
+		return
+	}
+	rematch.GuildID = g.GuildID
+	rematch.Engine = engine.NewWithDeck(g.Engine.Rules, g.Engine.CustomDeck, rematch.Engine.Seed)
+	rematch.TargetScore = g.TargetScore
+	rematch.TurnTimeout = g.TurnTimeout
+	rematch.MaxTimeouts = g.MaxTimeouts
+	rematch.HandSize = g.HandSize
+	rematch.MaxPlayers = g.MaxPlayers
+	rematch.ColorTimeout = g.ColorTimeout
+	rematch.ChallengeTimeout = g.ChallengeTimeout
+	rematch.KeepTimeout = g.KeepTimeout
+	rematch.JoinCode = g.JoinCode
+	rematch.commit()
+
+	// Rotate the dealer, the player after the last starter goes first
+	for seat := range g.Players {
+		player := g.Players[(seat+1)%len(g.Players)]
+
+		if player.IsBot() {
+			rematch.NewBot(player.Difficulty).Team = player.Team
+			continue
+		}
+
+		role := Normal
+		if player.User.ID == g.Host {
+			role = Host
+		}
+		rematch.NewPlayer(player.User, role, rematch.HandSize).Team = player.Team
+		rematch.mixJoin(player.User, i.ID)
+	}
+
+	// Players who don't want to play again can leave from the lobby
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: rematch.RenderEmbed(s),
+		Type: discordgo.InteractionResponseUpdateMessage,
 	})
 }
 
 // Start game
 func (g *Game) StartGame(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if g.Host == i.Member.User.ID {
+		// The start button is disabled with one player, but the interaction can still arrive
+		g.engineMux.Lock()
+		players := len(g.Players)
+		g.engineMux.Unlock()
+		if g.State != Lobby || players < 2 {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Data: &discordgo.InteractionResponseData{
+					Content: "Not enough players, at least 2 are needed to start.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+			})
+			return
+		}
+
+		if g.Engine.Rules.Partners {
+			if err := g.arrangeTeams(); err != nil {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Data: &discordgo.InteractionResponseData{
+						Content: "Can't start: " + err.Error() + ".",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+				})
+				return
+			}
+		}
+
+		g.deal()
 		g.State = Playing
+		g.recordDeal(g.HandSize)
+		g.StartTurnTimer(s)
 		// Send an update with the embed (you can modify the existing message or send a new one)
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Data: g.RenderEmbed(s),
 			Type: discordgo.InteractionResponseUpdateMessage,
 		})
-	} else if len(g.Players) >= 2 {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Data: &discordgo.InteractionResponseData{
-				Content: "Not enough players",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-		})
+		g.RunBots(s)
 	} else {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Data: &discordgo.InteractionResponseData{
@@ -120,7 +326,7 @@ func (g *Game) Delete(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			s.InteractionResponseDelete(interaction)
 		}()
 
-		delete(games, g.ID)
+		g.end()
 	} else {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Data: &discordgo.InteractionResponseData{
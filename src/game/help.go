@@ -0,0 +1,262 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Help buttons, followed by ":<page>"
+	HelpPreviousButton string = "help_previous"
+	HelpNextButton     string = "help_next"
+	// Jumps to the first page of a section
+	HelpSectionSelect string = "help_section"
+	// Pages before the card glossary
+	HELP_GUIDE_PAGES int = 4
+)
+
+// What every kind of card does when played
+var cardEffects = map[engine.CardType]string{
+	engine.NumberCard:        "Play it on the same color or number.",
+	engine.SkipCard:          "The next player loses their turn.",
+	engine.ReverseCard:       "Turn order changes direction. With two players it works like a Skip.",
+	engine.DrawTwoCard:       "The next player draws 2 cards and loses their turn.",
+	engine.WildCard:          "Play it on anything and pick the next color.",
+	engine.WildDrawFourCard:  "Pick the next color, the next player draws 4 cards and loses their turn. Only legal without a card of the current color, see the challenge page.",
+	engine.DrawOneCard:       "UNO Flip light side: the next player draws 1 card and loses their turn.",
+	engine.FlipCard:          "UNO Flip: every card turns over to the other side, the discard pile and hands included.",
+	engine.WildDrawTwoCard:   "UNO Flip light side: pick the next color, the next player draws 2 cards and loses their turn.",
+	engine.DrawFiveCard:      "UNO Flip dark side: the next player draws 5 cards and loses their turn.",
+	engine.SkipEveryoneCard:  "UNO Flip dark side: everyone else is skipped, you play again.",
+	engine.WildDrawColorCard: "UNO Flip dark side: pick a color, the next player draws until they get a card of that color.",
+}
+
+// Show the first page of the help guide, only to the user who asked
+func ShowHelp(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := renderHelp(i.GuildID, 0)
+	data.Flags = discordgo.MessageFlagsEphemeral
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: data,
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
+
+// Check if a component belongs to the help guide
+func IsHelpComponent(customID string) bool {
+	return strings.HasPrefix(customID, HelpPreviousButton+":") || strings.HasPrefix(customID, HelpNextButton+":") || customID == HelpSectionSelect
+}
+
+// Move the help guide to the page in the button or the section picked
+func HelpPage(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) {
+	value := ""
+	if data.CustomID == HelpSectionSelect {
+		if len(data.Values) > 0 {
+			value = data.Values[0]
+		}
+	} else {
+		_, value, _ = strings.Cut(data.CustomID, ":")
+	}
+	page, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: renderHelp(i.GuildID, page),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
+
+func renderHelp(guildID string, page int) *discordgo.InteractionResponseData {
+	settings := LoadSettings(guildID)
+	deck := settings.Deck
+	if deck == nil {
+		deck = engine.DefaultDeck
+	}
+	totalPages := HELP_GUIDE_PAGES + len(deck.Cards)
+	page = min(max(page, 0), totalPages-1)
+
+	var embed *discordgo.MessageEmbed
+	switch page {
+	case 0:
+		embed = helpButtons()
+	case 1:
+		embed = helpCards()
+	case 2:
+		embed = helpChallenge(settings)
+	case 3:
+		embed = helpRules(settings)
+	default:
+		embed = helpGlossary(deck.Cards[page-HELP_GUIDE_PAGES])
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: fmt.Sprintf("Page %d/%d", page+1, totalPages),
+	}
+
+	sections := []discordgo.SelectMenuOption{
+		{Label: "Buttons", Value: "0", Emoji: &discordgo.ComponentEmoji{Name: "🔘"}},
+		{Label: "Card effects", Value: "1", Emoji: &discordgo.ComponentEmoji{Name: "🃏"}},
+		{Label: "Wild Draw Four challenge", Value: "2", Emoji: &discordgo.ComponentEmoji{Name: "⚖️"}},
+		{Label: "House rules on this server", Value: "3", Emoji: &discordgo.ComponentEmoji{Name: "🏠"}},
+		{Label: "Card glossary", Value: strconv.Itoa(HELP_GUIDE_PAGES), Emoji: &discordgo.ComponentEmoji{Name: "📖"}},
+	}
+
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					&discordgo.Button{
+						Label:    "⬅️ Previous",
+						Style:    discordgo.SuccessButton,
+						CustomID: fmt.Sprintf("%s:%d", HelpPreviousButton, page-1),
+						Disabled: page <= 0,
+					},
+					&discordgo.Button{
+						Label:    "➡️ Next",
+						Style:    discordgo.SuccessButton,
+						CustomID: fmt.Sprintf("%s:%d", HelpNextButton, page+1),
+						Disabled: page >= totalPages-1,
+					},
+				},
+			},
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						CustomID:    HelpSectionSelect,
+						Placeholder: "Jump to a section",
+						Options:     sections,
+					},
+				},
+			},
+		},
+	}
+}
+
+// Helper function to explain the lobby and game buttons
+func helpButtons() *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "❓ How to play",
+		Description: "Start a lobby with `/uno start`. Get rid of all your cards to win, matches go on until someone reaches the target score.",
+		Color:       0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name: "Lobby",
+				Value: "• **Join** / **Leave** the game\n" +
+					"• **Start** deals the cards, only the host can start\n" +
+					"• **End Game** deletes the game, only the host can end it\n" +
+					"• **Switch Team** moves you to the other team when playing partners\n" +
+					"• The host picks house rules, scoring, the turn timer and bots from the menus",
+			},
+			{
+				Name: "Playing",
+				Value: "• **View Cards** opens your hand, only you can see it\n" +
+					"• Press a card in your hand to play it, cards you can't play are disabled\n" +
+					"• **Draw** takes a card, you can play it right away or keep it\n" +
+					"• ⬅️ / ➡️ page through big hands\n" +
+					"• **UNO!** when you play your second to last card\n" +
+					fmt.Sprintf("• **Catch!** someone who didn't call UNO within %d seconds before the next player moves, they draw 2 cards", int(UNO_WINDOW.Seconds())),
+			},
+			{
+				Name: "After the game",
+				Value: "• **Play Again** starts a rematch with the same players\n" +
+					"• `/uno replay` steps through the replay file from the end screen\n" +
+					"• `/uno stats`, `/uno leaderboard` and `/uno achievements` show how everyone is doing",
+			},
+		},
+	}
+}
+
+// Helper function to explain what every card does
+func helpCards() *discordgo.MessageEmbed {
+	types := []engine.CardType{engine.NumberCard, engine.SkipCard, engine.ReverseCard, engine.DrawTwoCard, engine.WildCard, engine.WildDrawFourCard}
+	names := []string{"Number", "Skip", "Reverse", "Draw Two", "Wild", "Wild Draw Four"}
+
+	var fields []*discordgo.MessageEmbedField
+	for index, cardType := range types {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  names[index],
+			Value: cardEffects[cardType],
+		})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "🃏 Card effects",
+		Description: "Play a card with the same color or symbol as the top card. Wild cards can be played on anything.",
+		Color:       0x00ff00,
+		Fields:      fields,
+	}
+}
+
+// Helper function to explain the Wild Draw Four challenge
+func helpChallenge(settings Settings) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: "⚖️ Wild Draw Four challenge",
+		Description: "A Wild Draw Four may only be played when you hold no card of the current color. " +
+			"The next player can **Challenge** it from their hand view, or **Ignore** it and draw 4.",
+		Color: 0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Challenge won", Value: "The Wild Draw Four was played with a card of the current color. Its player draws the 4 cards instead and the challenger plays on."},
+			{Name: "Challenge lost", Value: "The Wild Draw Four was legal. The challenger draws 6 cards and loses their turn."},
+			{Name: "No answer", Value: fmt.Sprintf("Players who don't answer within %d seconds accept the cards.", int(settings.ChallengeTimeout.Seconds()))},
+			{Name: "Stacking", Value: "With the Stacking house rule the challenge covers every stacked card. Won, the Wild Draw Four player draws them all. Lost, the challenger draws them all plus 2. Ignored, the challenger stacks another draw card on it or draws everything."},
+		},
+	}
+}
+
+// Helper function to list the house rules new lobbies start with in the guild
+func helpRules(settings Settings) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "🏠 House rules on this server",
+		Description: "New lobbies start with these rules. The host can change them in the lobby or with the `rules` option of `/uno start`, server managers change the defaults with `/uno settings`.",
+		Color:       0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{
+			rulesList(settings.Rules),
+			{
+				Name: "Defaults",
+				Value: fmt.Sprintf("• %d cards per hand\n• Up to %d players\n• %s",
+					settings.HandSize, settings.MaxPlayers, timerLabel(settings.TurnTimeout)),
+			},
+		},
+	}
+	if settings.Rules.Stacking {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Stacking and challenges",
+			Value: "A Wild Draw Four can still be challenged. Ignore the challenge to stack another draw card on it or draw everything.",
+		})
+	}
+	return embed
+}
+
+// Helper function to explain one card of the deck with its image
+func helpGlossary(definition engine.DeckCard) *discordgo.MessageEmbed {
+	card := engine.Card{Face: engine.Face{
+		Name:  definition.Name,
+		Link:  definition.Link,
+		Type:  engine.CardTypes[definition.Type],
+		Color: definition.Color,
+		Value: definition.Value,
+	}}
+
+	color := "Wild, any color"
+	if card.Color != "" {
+		color = colorEmoji(card.Color) + " " + strings.ToUpper(card.Color[:1]) + card.Color[1:]
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "📖 " + card.Name,
+		Description: cardEffects[card.Type],
+		Color:       0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Color", Value: color, Inline: true},
+			{Name: "Copies in the deck", Value: strconv.Itoa(definition.Copies), Inline: true},
+			{Name: "Points left in a hand", Value: strconv.Itoa(card.Points()), Inline: true},
+		},
+		Image: cardImage(card),
+	}
+}
@@ -0,0 +1,109 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+)
+
+const (
+	// Log entries shown while playing
+	RECENT_LOG_ENTRIES int = 5
+)
+
+// Something that happened in the game
+type LogEntry struct {
+	Time  time.Time
+	Round int
+	Text  string
+}
+
+// Append to the game log, entries are never changed or removed
+func (g *Game) record(text string) {
+	g.logMux.Lock()
+	defer g.logMux.Unlock()
+
+	g.Log = append(g.Log, LogEntry{
+		Time:  time.Now(),
+		Round: g.Round,
+		Text:  text,
+	})
+}
+
+// Last n log entries, oldest first
+func (g *Game) RecentLog(n int) []LogEntry {
+	g.logMux.Lock()
+	defer g.logMux.Unlock()
+
+	if len(g.Log) < n {
+		n = len(g.Log)
+	}
+	return append([]LogEntry{}, g.Log[len(g.Log)-n:]...)
+}
+
+// Log the start of a round
+func (g *Game) recordDeal(handSize int) {
+	g.record(fmt.Sprintf("🃏 Round %d: dealt %d cards to %d players, starting on **%s**", g.Round, handSize, len(g.Players), g.TopCard().Name))
+}
+
+// Log what the engine did
+func (g *Game) announce(events []engine.Event) {
+	for _, event := range events {
+		if text := g.describe(event); text != "" {
+			g.record(text)
+		}
+	}
+}
+
+// Describe an event for the log, empty for events not worth logging
+func (g *Game) describe(event engine.Event) string {
+	switch e := event.(type) {
+	case engine.CardPlayed:
+		text := fmt.Sprintf("%s played **%s**", g.Mention(e.Player), e.Card.Name)
+		if e.Card.DrawAmount() > 0 && g.Engine.Penalty > 0 {
+			text += fmt.Sprintf(", **+%d** pending", g.Engine.Penalty)
+		}
+		return text
+	case engine.JumpedIn:
+		return fmt.Sprintf("⚡ %s jumped in", g.Mention(e.Player))
+	case engine.CardsDrawn:
+		if len(e.Cards) == 1 {
+			return fmt.Sprintf("%s drew a card", g.Mention(e.Player))
+		}
+		return fmt.Sprintf("%s drew **%d** cards", g.Mention(e.Player), len(e.Cards))
+	case engine.CardKept:
+		return fmt.Sprintf("%s kept the card", g.Mention(e.Player))
+	case engine.ColorChosen:
+		return fmt.Sprintf("🎨 %s picked %s **%s**", g.Mention(e.Player), colorEmoji(e.Color), strings.ToUpper(e.Color))
+	case engine.ChallengeResolved:
+		if e.Won {
+			return fmt.Sprintf("⚖️ %s challenged %s and won", g.Mention(e.Challenger), g.Mention(e.Challenged))
+		}
+		return fmt.Sprintf("⚖️ %s challenged %s and lost", g.Mention(e.Challenger), g.Mention(e.Challenged))
+	case engine.TurnSkipped:
+		return fmt.Sprintf("⏭️ %s was skipped", g.Mention(e.Player))
+	case engine.DirectionReversed:
+		return "🔁 Direction reversed"
+	case engine.HandsSwapped:
+		return fmt.Sprintf("🔀 %s swapped hands with %s", g.Mention(e.Player), g.Mention(e.Target))
+	case engine.HandsRotated:
+		return "🔃 Every hand moved one seat along"
+	case engine.Flipped:
+		side := "light"
+		if e.Dark {
+			side = "dark"
+		}
+		return fmt.Sprintf("🔄 Every card flipped, the **%s** side is up!", side)
+	case engine.UnoCalled:
+		return fmt.Sprintf("📢 %s called **UNO!**", g.Mention(e.Player))
+	case engine.UnoMissed:
+		return fmt.Sprintf("⏰ %s didn't call **UNO!** in time, catch them before the next move!", g.Mention(e.Player))
+	case engine.UnoCaught:
+		return fmt.Sprintf("🚨 %s caught %s not calling UNO, **+2** cards!", g.Mention(e.Player), g.Mention(e.Target))
+	case engine.GameWon:
+		return fmt.Sprintf("🏆 %s emptied their hand", g.Mention(e.Player))
+	}
+	return ""
+}
@@ -0,0 +1,201 @@
+package game
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// /uno subcommands doing what the game message buttons do, for when their interaction expired
+
+// Open the players hand in a new message, the old hand view stops updating
+func (g *Game) ShowHand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	player := g.GetPlayer(i.Member.User.ID)
+	switch {
+	case player == nil:
+		replyEphemeral(s, i, "You are not in this game.")
+		return
+	case g.State != Playing:
+		replyEphemeral(s, i, "There are no hands to show, the game isn't being played.")
+		return
+	}
+
+	if player.Interaction != nil {
+		s.InteractionResponseDelete(player.Interaction)
+	}
+	g.ViewCards(s, i)
+}
+
+// Post the game message again, the new message is updated from now on
+func (g *Game) ShowStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: g.RenderEmbed(s),
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Failed to repost game %s: %v", g.ID, err)
+		return
+	}
+
+	g.Interaction = i.Interaction
+	g.MessageID = ""
+}
+
+// End the game, for the host or members who can manage the server
+func (g *Game) Stop(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !g.canModerate(i) {
+		replyEphemeral(s, i, "Only the host or members who can manage the server can end the game.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Game ended",
+		Description: fmt.Sprintf("Game was ended by <@%s>", i.Member.User.ID),
+		Color:       0xFF0000, // Red color code
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{embed},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+
+	g.end()
+	g.close(s, embed)
+}
+
+// Remove another player from the game
+func (g *Game) Kick(s *discordgo.Session, i *discordgo.InteractionCreate, userID string) {
+	switch {
+	case !g.canModerate(i):
+		replyEphemeral(s, i, "Only the host or members who can manage the server can kick players.")
+		return
+	case userID == i.Member.User.ID:
+		replyEphemeral(s, i, "Use `/uno leave` to leave the game yourself.")
+		return
+	case g.State == EndScreen || g.GetPlayer(userID) == nil:
+		replyEphemeral(s, i, fmt.Sprintf("<@%s> is not in this game.", userID))
+		return
+	}
+
+	g.RemovePlayer(s, userID)
+	if g.State == Lobby {
+		g.RenderUpdate(s)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Content:         fmt.Sprintf("👢 <@%s> was kicked from the game by <@%s>.", userID, i.Member.User.ID),
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{userID}},
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
+
+// Make another human player the host
+func (g *Game) TransferHost(s *discordgo.Session, i *discordgo.InteractionCreate, userID string) {
+	player := g.GetPlayer(userID)
+	switch {
+	case !g.canModerate(i):
+		replyEphemeral(s, i, "Only the host or members who can manage the server can pick a new host.")
+		return
+	case player == nil || player.IsBot():
+		replyEphemeral(s, i, fmt.Sprintf("<@%s> is not a player in this game.", userID))
+		return
+	case userID == g.Host:
+		replyEphemeral(s, i, fmt.Sprintf("<@%s> is the host already.", userID))
+		return
+	}
+
+	if host := g.GetPlayer(g.Host); host != nil {
+		host.Role = Normal
+	}
+	player.Role = Host
+	g.Host = userID
+	if g.State == Playing {
+		g.record(fmt.Sprintf("👑 %s is the new host.", g.Mention(userID)))
+	}
+	g.RenderUpdate(s)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Content:         fmt.Sprintf("👑 <@%s> is the new host.", userID),
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{userID}},
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
+
+// Player leaves the game without the leave button
+func (g *Game) Leave(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if g.State == EndScreen || g.GetPlayer(i.Member.User.ID) == nil {
+		replyEphemeral(s, i, "You are not in this game.")
+		return
+	}
+
+	g.RemovePlayer(s, i.Member.User.ID)
+	if g.State == Lobby {
+		g.RenderUpdate(s)
+	}
+
+	replyEphemeral(s, i, "You left the game.")
+}
+
+// Check if the user is the host or can manage the server
+func (g *Game) canModerate(i *discordgo.InteractionCreate) bool {
+	return i.Member.User.ID == g.Host || i.Member.Permissions&discordgo.PermissionManageServer != 0
+}
+
+// Replace the game message with an embed and delete the hand views
+func (g *Game) close(s *discordgo.Session, embed *discordgo.MessageEmbed) {
+	embeds := []*discordgo.MessageEmbed{embed}
+	components := []discordgo.MessageComponent{}
+
+	var err error
+	if g.MessageID != "" {
+		_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:    g.ChannelID,
+			ID:         g.MessageID,
+			Embeds:     &embeds,
+			Components: &components,
+		})
+	} else if g.Interaction != nil {
+		_, err = s.InteractionResponseEdit(g.Interaction, &discordgo.WebhookEdit{
+			Embeds:     &embeds,
+			Components: &components,
+		})
+	}
+	if err != nil {
+		// Interaction tokens expire after 15 minutes
+		log.Printf("Failed to close game view: %v", err)
+	}
+
+	for _, player := range g.Players {
+		if player.Interaction != nil {
+			s.InteractionResponseDelete(player.Interaction)
+			player.Interaction = nil
+		}
+	}
+}
+
+// Helper function to render the game message once the last human left
+func everyoneLeft() *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "Game ended",
+		Description: "Everyone left the game",
+		Color:       0xFF0000, // Red color code
+	}
+}
+
+// Answer only the user who used the command
+func replyEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
@@ -1,6 +1,12 @@
 package game
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"fmt"
+
+	"github.com/Ranzz02/uno-discord-bot/src/ai"
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/bwmarrin/discordgo"
+)
 
 type PlayerRole int
 
@@ -10,31 +16,135 @@ const (
 )
 
 type Player struct {
-	User          *discordgo.User
-	Hand          []Card
-	Role          PlayerRole
-	Interaction   *discordgo.Interaction
-	LastDrawnCard *Card
-	Page          int
+	*engine.Player
+	User        *discordgo.User
+	Role        PlayerRole
+	Interaction *discordgo.Interaction
+	Page        int
+	Timeouts    int         // Turns in a row that ran out of time
+	Strategy    ai.Strategy // Set for computer players
+	Difficulty  ai.Difficulty
+}
+
+// Check if the player is controlled by the computer
+func (p *Player) IsBot() bool {
+	return p.Strategy != nil
+}
+
+func (g *Game) NewPlayer(user *discordgo.User, role PlayerRole, initCards int) *Player {
+	// Players are saved by the autosave
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+
+	player := &Player{
+		Player: g.Engine.AddPlayer(user.ID, initCards),
+		User:   user,
+		Role:   role,
+		Page:   0,
+	}
+	// Counted before picking a team, so the team count includes the new player
+	g.Players = append(g.Players, player)
+	if g.Engine.Rules.Partners {
+		g.assignTeam(player)
+	}
+	return player
 }
 
-func (p *Player) HasValidPreviousPlay(g *Game) bool {
-	for _, card := range p.Hand {
-		if g.CanPlayPreviousCard(&card) {
-			return true
+// Number of players that aren't computer players
+func (g *Game) Humans() int {
+	humans := 0
+	for _, player := range g.Players {
+		if !player.IsBot() {
+			humans++
 		}
 	}
+	return humans
+}
 
-	return false
+// Mention a player, computer players can't be pinged
+func (g *Game) Mention(userId string) string {
+	player := g.GetPlayer(userId)
+	if player != nil && player.IsBot() {
+		return "🤖 **" + player.User.Username + "**"
+	}
+	// Replays can be watched anywhere, nobody gets pinged
+	if player != nil && g.State == Replaying {
+		return "**" + player.User.Username + "**"
+	}
+	return "<@" + userId + ">"
 }
 
-func (g *Game) NewPlayer(user *discordgo.User, role PlayerRole, initCards int) {
-	g.Players = append(g.Players, &Player{
-		User: user,
-		Hand: DrawCards(g, initCards),
-		Role: role,
-		Page: 0,
-	})
+// Remove player from the game, their cards go back in the deck.
+// The host is handed over and a running game with one player left ends.
+func (g *Game) RemovePlayer(s *discordgo.Session, userId string) {
+	g.removePlayer(s, userId, "👋 %s left the game.")
+}
+
+// Remove a player, logging why with their mention in place of %s
+func (g *Game) removePlayer(s *discordgo.Session, userId string, reason string) {
+	leaver := g.GetPlayer(userId)
+	if leaver == nil {
+		return
+	}
+	mention := g.Mention(userId)
+
+	// Delete view hand
+	if leaver.Interaction != nil {
+		s.InteractionResponseDelete(leaver.Interaction)
+		leaver.Interaction = nil
+	}
+
+	g.engineMux.Lock()
+	events := g.Engine.RemovePlayer(userId)
+	if g.State == Playing {
+		g.recordStep(engine.Step{Kind: engine.LeaveStep, Player: userId})
+	}
+
+	var players []*Player
+	for _, player := range g.Players {
+		if player != leaver {
+			players = append(players, player)
+		}
+	}
+	g.Players = players
+	g.engineMux.Unlock()
+
+	// Computer players don't play on their own
+	if g.Humans() == 0 {
+		g.end()
+		g.close(s, everyoneLeft())
+		return
+	}
+
+	// Hand the host over to the next human player
+	if g.Host == userId {
+		for _, player := range g.Players {
+			if !player.IsBot() {
+				g.Host = player.User.ID
+				player.Role = Host
+				break
+			}
+		}
+	}
+
+	if g.State != Playing {
+		return
+	}
+
+	g.record(fmt.Sprintf(reason, mention))
+	if len(g.Players) == 1 {
+		g.EndGame(s, g.Players[0])
+		return
+	}
+
+	// It was the leavers turn
+	for _, event := range events {
+		if _, ok := event.(engine.TurnStarted); ok {
+			g.StartTurnTimer(s)
+		}
+	}
+	g.RenderUpdate(s)
+	g.RunBots(s)
 }
 
 // Get player with id
@@ -47,27 +157,30 @@ func (g *Game) GetPlayer(userId string) *Player {
 	return nil
 }
 
-// Get current player
-func (g *Game) GetCurrentPlayer() *Player {
-	return g.Players[g.CurrentTurn]
-}
+// Move a player's hand view a page back or forward, false when there is no such page
+func (g *Game) TurnPage(userId string, step int) bool {
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
 
-// Get previous player
-func (g *Game) GetPreviousPlayer() *Player {
-	if g.Reversed {
-		// If the direction is reversed, move backwards.
-		return g.Players[(g.CurrentTurn+1+len(g.Players))%len(g.Players)]
+	player := g.GetPlayer(userId)
+	if player == nil {
+		return false
+	}
+	totalPages := (len(player.Hand) + MAX_CARDS_PER_PAGE - 1) / MAX_CARDS_PER_PAGE
+	page := player.Page + step
+	if page < 0 || page >= totalPages {
+		return false
 	}
-	// Otherwise, move forwards.
-	return g.Players[(g.CurrentTurn-1)%len(g.Players)]
+	player.Page = page
+	return true
+}
+
+// Get current player
+func (g *Game) GetCurrentPlayer() *Player {
+	return g.GetPlayer(g.Engine.CurrentPlayer().ID)
 }
 
 // Get next player
 func (g *Game) GetNextPlayer() *Player {
-	if g.Reversed {
-		// If the direction is reversed, move backwards.
-		return g.Players[(g.CurrentTurn-1+len(g.Players))%len(g.Players)]
-	}
-	// Otherwise, move forwards.
-	return g.Players[(g.CurrentTurn+1)%len(g.Players)]
+	return g.GetPlayer(g.Engine.NextPlayer().ID)
 }
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+const (
+	// Modal asking for the code of a private lobby
+	JoinCodeModal string = "join_code_modal"
+	JoinCodeInput string = "join_code"
+	// Codes skip letters and numbers that look alike
+	JOIN_CODE_ALPHABET string = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	JOIN_CODE_LENGTH   int    = 6
+)
+
+// Make the lobby private, players need the code from the host to join
+func (g *Game) lock() {
+	code, err := gonanoid.Generate(JOIN_CODE_ALPHABET, JOIN_CODE_LENGTH)
+	if err != nil {
+		log.Printf("Failed to create join code: %v", err)
+		return
+	}
+	g.JoinCode = code
+}
+
+// Tell the host the code of their private lobby
+func (g *Game) SendJoinCode(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if g.JoinCode == "" {
+		return
+	}
+
+	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: "🔒 Your lobby is private, share the join code `" + g.JoinCode + "` with the players you want to invite.",
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		log.Printf("Failed to send join code: %v", err)
+	}
+}
+
+// Helper function to render the join code modal
+func joinCodeModal() *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		CustomID: JoinCodeModal,
+		Title:    "Join private UNO game",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  JoinCodeInput,
+						Label:     "Join code from the host",
+						Style:     discordgo.TextInputShort,
+						Required:  true,
+						MinLength: JOIN_CODE_LENGTH,
+						MaxLength: JOIN_CODE_LENGTH,
+					},
+				},
+			},
+		},
+	}
+}
+
+// Join a private lobby with the code typed in the modal
+func (g *Game) JoinWithCode(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ModalSubmitInteractionData) {
+	code := ""
+	for _, row := range data.Components {
+		if actions, ok := row.(*discordgo.ActionsRow); ok {
+			for _, component := range actions.Components {
+				if input, ok := component.(*discordgo.TextInput); ok && input.CustomID == JoinCodeInput {
+					code = strings.ToUpper(strings.TrimSpace(input.Value))
+				}
+			}
+		}
+	}
+
+	reason := g.joinError(i.Member.User.ID)
+	if reason == "" && code != g.JoinCode {
+		reason = "That isn't the join code of this game."
+	}
+	if reason != "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: reason,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	g.NewPlayer(i.Member.User, Normal, g.HandSize)
+	g.mixJoin(i.Member.User, i.ID)
+
+	// Modal was opened from the lobby, so its message can be updated
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: g.RenderEmbed(s),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
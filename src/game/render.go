@@ -3,8 +3,13 @@ package game
 import (
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Ranzz02/uno-discord-bot/src/ai"
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -12,6 +17,9 @@ import (
 func (g *Game) RenderEmbed(s *discordgo.Session) *discordgo.InteractionResponseData {
 	switch g.State {
 	case Lobby: // Lobby / start of game
+		g.engineMux.Lock()
+		defer g.engineMux.Unlock()
+
 		components := []discordgo.MessageComponent{
 			&discordgo.ActionsRow{
 				Components: []discordgo.MessageComponent{
@@ -36,17 +44,48 @@ func (g *Game) RenderEmbed(s *discordgo.Session) *discordgo.InteractionResponseD
 						Style:    discordgo.DangerButton,
 						CustomID: EndButton,
 					},
+					&discordgo.Button{
+						Label:    "Switch Team",
+						Style:    discordgo.SecondaryButton,
+						CustomID: SwitchTeamButton,
+						Disabled: !g.Engine.Rules.Partners,
+					},
+				},
+			},
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					rulesSelect(RulesSelect, g.Engine.Rules),
+				},
+			},
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					scoringSelect(g),
+				},
+			},
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					timerSelect(TimerSelect, g.TurnTimeout, g.MaxTimeouts),
+				},
+			},
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					botSelect(g),
 				},
 			},
 		}
 
+		fields := append(playersList(g), rulesList(g.Engine.Rules), lobbySettings(g))
+		if g.Fair != nil {
+			fields = append(fields, fairnessField(g))
+		}
+
 		embed := &discordgo.MessageEmbed{
 			Title:       "UNO Game Lobby",
 			Description: "Welcome to the UNO game lobby! Press 'Join' to join the game, or the host can press 'Start' to begin.",
-			Fields:      playersList(g),
+			Fields:      fields,
 			Color:       0x00ff00,
 			Image: &discordgo.MessageEmbedImage{
-				URL: Cards[0].Link,
+				URL: g.Engine.Definition().Back,
 			},
 		}
 
@@ -55,30 +94,17 @@ func (g *Game) RenderEmbed(s *discordgo.Session) *discordgo.InteractionResponseD
 			Components: components,
 		}
 	case Playing: // Playing
+		g.engineMux.Lock()
+		defer g.engineMux.Unlock()
+
 		// Check if the top card is a Wild Card
 		topCard := g.TopCard()
 		var colorDisplay string
 
 		var wildCardColor *discordgo.MessageEmbedField
-		if topCard.Type == WildCard || topCard.Type == WildDrawFourCard {
-			colorEmojiMap := map[string]string{
-				"red":    "🟥",
-				"green":  "🟩",
-				"blue":   "🟦",
-				"yellow": "🟨",
-			}
-
-			selectedColor := "red"
-			if g.ColorData.CurrentColor != nil {
-				selectedColor = *g.ColorData.CurrentColor
-			}
-
-			colorEmoji, exists := colorEmojiMap[selectedColor]
-			if !exists {
-				colorEmoji = "⬜" // Default if color isn't set
-			}
-
-			colorDisplay = fmt.Sprintf("%s %s", colorEmoji, strings.ToUpper(selectedColor))
+		if topCard.IsWild() {
+			selectedColor := g.Engine.Color
+			colorDisplay = fmt.Sprintf("%s %s", colorEmoji(selectedColor), strings.ToUpper(selectedColor))
 
 			wildCardColor = &discordgo.MessageEmbedField{
 				Name:   "Wild Color:",
@@ -94,7 +120,13 @@ func (g *Game) RenderEmbed(s *discordgo.Session) *discordgo.InteractionResponseD
 						Label:    "UNO!",
 						Style:    discordgo.PrimaryButton,
 						CustomID: UNOButton,
-						Disabled: !g.UNO,
+						Disabled: g.Engine.UnoPending == "",
+					},
+					&discordgo.Button{
+						Label:    "Catch!",
+						Style:    discordgo.DangerButton,
+						CustomID: CatchButton,
+						Disabled: g.Engine.Uncalled == "",
 					},
 					&discordgo.Button{
 						Label:    "View Cards",
@@ -121,14 +153,70 @@ func (g *Game) RenderEmbed(s *discordgo.Session) *discordgo.InteractionResponseD
 			fields = append(fields, wildCardColor)
 		}
 
+		// Add UNO Flip side
+		if g.Engine.Rules.Flip {
+			side := "☀️ Light side up"
+			if g.Engine.Dark {
+				side = "🌑 Dark side up"
+			}
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   "UNO Flip:",
+				Value:  side,
+				Inline: true,
+			})
+		}
+
+		// Add stacked penalty
+		if g.Engine.Penalty > 0 {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   "Pending penalty:",
+				Value:  fmt.Sprintf("**+%d** for %s, stack or draw!", g.Engine.Penalty, g.Mention(g.GetCurrentPlayer().User.ID)),
+				Inline: true,
+			})
+		}
+
+		// Add match scores
+		if g.TargetScore > 0 {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   fmt.Sprintf("Round %d, first to %d points:", g.Round, g.TargetScore),
+				Value:  scoreboard(g),
+				Inline: false,
+			})
+		}
+
+		// Add turn countdown
+		if !g.TurnData.Deadline.IsZero() {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   "⏰ Turn timer:",
+				Value:  fmt.Sprintf("Auto-draw <t:%d:R>", g.TurnData.Deadline.Unix()),
+				Inline: true,
+			})
+		}
+
+		// Add recent moves
+		if recent := g.RecentLog(RECENT_LOG_ENTRIES); len(recent) > 0 {
+			var lines []string
+			for _, entry := range recent {
+				lines = append(lines, entry.Text)
+			}
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   "📜 Recent moves:",
+				Value:  strings.Join(lines, "\n"),
+				Inline: false,
+			})
+		}
+
 		embed := &discordgo.MessageEmbed{
 			Title:       "It's " + g.GetCurrentPlayer().User.Username + " turn!",
 			Description: fmt.Sprintf("Current card is: **%s**", topCard.Name),
 			Color:       0x00ff00,
 			Fields:      fields,
-			Image: &discordgo.MessageEmbedImage{
-				URL: g.TopCard().Link,
-			},
+			Image:       cardImage(topCard),
+		}
+		if g.Fair != nil {
+			embed.Footer = &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("🔒 Commitment %s, first deck %s", g.Fair.Commitment, g.FairDeckHash),
+			}
 		}
 
 		return &discordgo.InteractionResponseData{
@@ -136,11 +224,11 @@ func (g *Game) RenderEmbed(s *discordgo.Session) *discordgo.InteractionResponseD
 			Components: components,
 		}
 	case EndScreen:
-		var winner *Player
+		winner := g.Winner
+		winners := g.Team(winner)
 		var players []*Player
 		for _, player := range g.Players {
-			if len(player.Hand) <= 0 {
-				winner = player
+			if containsPlayer(winners, player) {
 				continue
 			}
 			players = append(players, player)
@@ -148,7 +236,39 @@ func (g *Game) RenderEmbed(s *discordgo.Session) *discordgo.InteractionResponseD
 
 		var playerList string
 		for _, player := range players {
-			playerList = playerList + fmt.Sprintf("<@%s> **__%d__** cards left!\n", player.User.ID, len(player.Hand))
+			playerList = playerList + fmt.Sprintf("%s **__%d__** cards left!\n", g.Mention(player.User.ID), len(player.Hand))
+		}
+
+		winnerTitle := "Winner 👑"
+		if len(winners) > 1 {
+			winnerTitle = "Winning team 👑"
+		}
+
+		fields := []*discordgo.MessageEmbedField{
+			{
+				Name:   winnerTitle,
+				Value:  teamName(g, winner),
+				Inline: false,
+			},
+			{
+				Name:   "Players",
+				Value:  playerList,
+				Inline: false,
+			},
+		}
+
+		// Add final match scores
+		if g.TargetScore > 0 {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   fmt.Sprintf("Scoreboard after %d rounds", g.Round),
+				Value:  scoreboard(g),
+				Inline: false,
+			})
+		}
+
+		// Reveal the seeds
+		if g.Fair != nil {
+			fields = append(fields, proofField(g))
 		}
 
 		embeds :=
@@ -157,21 +277,13 @@ func (g *Game) RenderEmbed(s *discordgo.Session) *discordgo.InteractionResponseD
 					Title:       "UNO Game Ended",
 					Description: "Game has come to an end",
 					Color:       0x00ff00,
-					Fields: []*discordgo.MessageEmbedField{
-						{
-							Name:   "Winner 👑",
-							Value:  fmt.Sprintf("<@%s>", winner.User.ID),
-							Inline: false,
-						},
-						{
-							Name:   "Players",
-							Value:  playerList,
-							Inline: false,
-						},
-					},
+					Fields:      fields,
 					Thumbnail: &discordgo.MessageEmbedThumbnail{
 						URL: winner.User.AvatarURL("1024"),
 					},
+					Footer: &discordgo.MessageEmbedFooter{
+						Text: fmt.Sprintf("Seed %d, start with /uno start seed:%d to deal this game again", g.Engine.Seed, g.Engine.Seed),
+					},
 				},
 			}
 
@@ -198,6 +310,234 @@ func (g *Game) RenderEmbed(s *discordgo.Session) *discordgo.InteractionResponseD
 	}
 }
 
+// Helper function to render the house rules picker
+func rulesSelect(customID string, rules engine.Rules) discordgo.SelectMenu {
+	options := []discordgo.SelectMenuOption{
+		{Label: "Stacking", Value: StackingRule, Default: rules.Stacking, Description: "Answer a draw card with the same one"},
+		{Label: "+4 on +2", Value: StackDrawFourRule, Default: rules.StackDrawFourOnDrawTwo, Description: "Stack a Wild Draw Four on a Draw Two"},
+		{Label: "Seven-O", Value: SevenORule, Default: rules.SevenO, Description: "7 swaps hands, 0 rotates all hands"},
+		{Label: "Jump-in", Value: JumpInRule, Default: rules.JumpIn, Description: "Play an identical card out of turn"},
+		{Label: "Partners", Value: PartnersRule, Default: rules.Partners, Description: "Teams of 2 sit opposite and win together"},
+		{Label: "UNO Flip", Value: FlipRule, Default: rules.Flip, Description: "Double-sided deck with a light and dark side"},
+	}
+
+	minValues := 0
+	return discordgo.SelectMenu{
+		CustomID:    customID,
+		Placeholder: "📜 House rules: official rules",
+		MinValues:   &minValues,
+		MaxValues:   len(options),
+		Options:     options,
+	}
+}
+
+// Helper function to return the emoji for a card color
+func colorEmoji(color string) string {
+	switch color {
+	case "red":
+		return "🟥"
+	case "blue":
+		return "🟦"
+	case "green":
+		return "🟩"
+	case "yellow":
+		return "🟨"
+	case "pink":
+		return "🩷"
+	case "teal":
+		return "🩵"
+	case "orange":
+		return "🟧"
+	case "purple":
+		return "🟪"
+	default:
+		return "⬜" // Wild or undefined color
+	}
+}
+
+// Helper function to show a card image, UNO Flip cards have none
+func cardImage(card engine.Card) *discordgo.MessageEmbedImage {
+	if card.Link == "" {
+		return nil
+	}
+	return &discordgo.MessageEmbedImage{URL: card.Link}
+}
+
+// Helper function to return the enabled house rules
+func rulesList(rules engine.Rules) *discordgo.MessageEmbedField {
+	var enabled []string
+	if rules.Stacking {
+		enabled = append(enabled, "Stacking Draw Two / Wild Draw Four")
+	}
+	if rules.StackDrawFourOnDrawTwo {
+		enabled = append(enabled, "Wild Draw Four can be stacked on Draw Two")
+	}
+	if rules.SevenO {
+		enabled = append(enabled, "Seven-O: 7 swaps hands, 0 rotates all hands")
+	}
+	if rules.JumpIn {
+		enabled = append(enabled, "Jump-in: play an identical card out of turn")
+	}
+	if rules.Partners {
+		enabled = append(enabled, "Partners: teams of 2 sit opposite, share points and win together")
+	}
+	if rules.Flip {
+		enabled = append(enabled, "UNO Flip: Flip cards turn every card over to the dark side and back")
+	}
+
+	value := "None, official rules"
+	if len(enabled) > 0 {
+		value = "• " + strings.Join(enabled, "\n• ")
+	}
+
+	return &discordgo.MessageEmbedField{
+		Name:   "House rules",
+		Value:  value,
+		Inline: false,
+	}
+}
+
+// Helper function to return the lobby settings players join with
+func lobbySettings(g *Game) *discordgo.MessageEmbedField {
+	scoring := "Single round"
+	if g.TargetScore > 0 {
+		scoring = fmt.Sprintf("Match, first to %d points", g.TargetScore)
+	}
+	lobby := "🔓 Public, anyone can join"
+	if g.JoinCode != "" {
+		lobby = "🔒 Private, joining needs the code from the host"
+	}
+
+	return &discordgo.MessageEmbedField{
+		Name: "Settings",
+		Value: fmt.Sprintf("• %d cards per hand\n• Up to %d players\n• %s\n• %s\n• %s",
+			g.HandSize, g.MaxPlayers, timerLabel(g.TurnTimeout), scoring, lobby),
+		Inline: false,
+	}
+}
+
+// Helper function to describe a turn timer
+func timerLabel(timeout time.Duration) string {
+	if timeout <= 0 {
+		return "No turn timer"
+	}
+	return fmt.Sprintf("%d seconds per turn", int(timeout.Seconds()))
+}
+
+// Helper function to render the match target picker
+func scoringSelect(g *Game) discordgo.SelectMenu {
+	var options []discordgo.SelectMenuOption
+	for _, target := range TargetScores {
+		option := discordgo.SelectMenuOption{
+			Label:   fmt.Sprintf("Match, first to %d points", target),
+			Value:   strconv.Itoa(target),
+			Default: g.TargetScore == target,
+		}
+		if target == 0 {
+			option.Label = "Single round"
+		}
+		if target == DEFAULT_TARGET_SCORE {
+			option.Description = "Official rules"
+		}
+		options = append(options, option)
+	}
+
+	return discordgo.SelectMenu{
+		CustomID: ScoringSelect,
+		Options:  options,
+	}
+}
+
+// Helper function to render the turn timer picker
+func timerSelect(customID string, selected time.Duration, maxTimeouts int) discordgo.SelectMenu {
+	var options []discordgo.SelectMenuOption
+	for _, timeout := range TurnTimeouts {
+		label := fmt.Sprintf("%d seconds per turn", int(timeout.Seconds()))
+		if timeout > time.Minute {
+			label = fmt.Sprintf("%d minutes per turn", int(timeout.Minutes()))
+		}
+
+		option := discordgo.SelectMenuOption{
+			Label:   label,
+			Value:   strconv.Itoa(int(timeout.Seconds())),
+			Default: selected == timeout,
+		}
+		if timeout == 0 {
+			option.Label = "No turn timer"
+		}
+		if timeout == DEFAULT_TURN_TIMEOUT && maxTimeouts > 0 {
+			option.Description = fmt.Sprintf("AFK players are removed after %d timeouts", maxTimeouts)
+		}
+		options = append(options, option)
+	}
+
+	return discordgo.SelectMenu{
+		CustomID: customID,
+		Options:  options,
+	}
+}
+
+// Helper function to render the add bot picker
+func botSelect(g *Game) discordgo.SelectMenu {
+	var options []discordgo.SelectMenuOption
+	for _, difficulty := range ai.Difficulties {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: difficulty.String() + " bot",
+			Value: strconv.Itoa(int(difficulty)),
+		})
+	}
+	options[ai.Easy].Description = "Plays a random card"
+	options[ai.Medium].Description = "Saves wilds, picks the color it holds most"
+	options[ai.Hard].Description = "Counts cards and goes after whoever is winning"
+	if len(g.Players) > g.Humans() {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       "Remove bot",
+			Value:       RemoveBotOption,
+			Description: "Removes the last computer player that joined",
+		})
+	}
+
+	return discordgo.SelectMenu{
+		CustomID:    AddBotSelect,
+		Placeholder: "🤖 Add a computer player",
+		Options:     options,
+	}
+}
+
+// Helper function to return the match scores, highest first
+func scoreboard(g *Game) string {
+	// Partners are listed once per team
+	var players []*Player
+	listed := map[string]bool{}
+	for _, player := range g.Players {
+		if !listed[g.scoreKey(player)] {
+			listed[g.scoreKey(player)] = true
+			players = append(players, player)
+		}
+	}
+	sort.SliceStable(players, func(a, b int) bool {
+		return g.Scores[g.scoreKey(players[a])] > g.Scores[g.scoreKey(players[b])]
+	})
+
+	var lines []string
+	for rank, player := range players {
+		lines = append(lines, fmt.Sprintf("%d. %s **__%d__** points", rank+1, teamName(g, player), g.Scores[g.scoreKey(player)]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Helper function to name a player, or their whole team in partners mode
+func teamName(g *Game, player *Player) string {
+	var mentions []string
+	for _, member := range g.Team(player) {
+		mentions = append(mentions, g.Mention(member.User.ID))
+	}
+	if len(mentions) == 1 && (!g.Engine.Rules.Partners || player.Team == 0) {
+		return mentions[0]
+	}
+	return fmt.Sprintf("Team %d (%s)", player.Team, strings.Join(mentions, " & "))
+}
+
 // Helper function to return PlayerList
 func playersList(g *Game) []*discordgo.MessageEmbedField {
 	// Create the player list as a string (user names or user IDs)
@@ -205,11 +545,15 @@ func playersList(g *Game) []*discordgo.MessageEmbedField {
 	currentPlayerID := g.GetCurrentPlayer().User.ID
 
 	for _, player := range g.Players {
-		playerFormat := fmt.Sprintf("<@%s>: **__%d__**", player.User.ID, len(player.Hand))
+		name := g.Mention(player.User.ID)
+		if g.Engine.Rules.Partners {
+			name = fmt.Sprintf("`T%d` %s", player.Team, name)
+		}
+		playerFormat := fmt.Sprintf("%s: **__%d__**", name, len(player.Hand))
 
 		if g.State == Playing && player.User.ID == currentPlayerID {
 			// Replace player.UserID with player.Name if you want to display usernames instead of user IDs
-			playerFormat = fmt.Sprintf("> 🎯 <@%s>: **__%d__**", player.User.ID, len(player.Hand))
+			playerFormat = fmt.Sprintf("> 🎯 %s: **__%d__**", name, len(player.Hand))
 		}
 		// Replace player.UserID with player.Name if you want to display usernames instead of user IDs
 		playerNames = append(playerNames, playerFormat) // Mention the user using the Discord format
@@ -228,6 +572,9 @@ func playersList(g *Game) []*discordgo.MessageEmbedField {
 }
 
 func (g *Game) RenderPlayerHand(playerID string) *discordgo.InteractionResponseData {
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+
 	player := g.GetPlayer(playerID)
 	if player == nil {
 		return nil
@@ -267,25 +614,19 @@ func (g *Game) RenderPlayerHand(playerID string) *discordgo.InteractionResponseD
 
 	var cardButtons []discordgo.MessageComponent
 	for _, card := range player.Hand[startIdx:endIdx] {
-		var colorEmoji string
-		switch strings.Split(card.Name, "-")[0] {
-		case "red":
-			colorEmoji = "🟥"
-		case "blue":
-			colorEmoji = "🟦"
-		case "green":
-			colorEmoji = "🟩"
-		case "yellow":
-			colorEmoji = "🟨"
-		default:
-			colorEmoji = "⬜" // Wild or undefined color
+		// Highlight cards that can be stacked on the penalty or jumped in with
+		isTurn := g.GetCurrentPlayer().User.ID == player.User.ID
+		jumpIn := g.Engine.CanJumpIn(card)
+		style := discordgo.PrimaryButton
+		if g.Engine.CanStack(card) || jumpIn {
+			style = discordgo.SuccessButton
 		}
 
 		cardButtons = append(cardButtons, &discordgo.Button{
-			Label:    colorEmoji + strings.ToUpper(card.Name),
-			Style:    discordgo.PrimaryButton,
+			Label:    colorEmoji(card.Color) + strings.ToUpper(card.Name),
+			Style:    style,
 			CustomID: "card-" + card.ID,
-			Disabled: g.GetCurrentPlayer().User.ID != player.User.ID || !g.CanPlayCard(&card), // Disable if not player's turn
+			Disabled: !jumpIn && (!isTurn || !g.CanPlayCard(&card)), // Disable if not player's turn
 		})
 	}
 
@@ -301,6 +642,11 @@ func (g *Game) RenderPlayerHand(playerID string) *discordgo.InteractionResponseD
 		})
 	}
 
+	drawLabel := "Draw card"
+	if g.Engine.Penalty > 0 {
+		drawLabel = fmt.Sprintf("Draw %d cards", g.Engine.Penalty)
+	}
+
 	rows = append(rows, &discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{
 			&discordgo.Button{
@@ -316,7 +662,7 @@ func (g *Game) RenderPlayerHand(playerID string) *discordgo.InteractionResponseD
 				Disabled: len(player.Hand) <= MAX_CARDS_PER_PAGE || player.Page >= totalPages-1, // Disable if not player's turn
 			},
 			&discordgo.Button{
-				Label:    "Draw card",
+				Label:    drawLabel,
 				Style:    discordgo.SecondaryButton,
 				CustomID: "draw-card",
 				Disabled: g.GetCurrentPlayer().User.ID != player.User.ID,
@@ -339,8 +685,24 @@ func (g *Game) RenderPlayerHand(playerID string) *discordgo.InteractionResponseD
 }
 
 func (g *Game) RenderUpdate(s *discordgo.Session) {
+	// Game was ended or everyone left, the game message was already replaced
+	if g.State == Ended {
+		return
+	}
+
 	// Update the game view
-	if g.Interaction != nil {
+	if g.MessageID != "" {
+		data := g.RenderEmbed(s)
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:    g.ChannelID,
+			ID:         g.MessageID,
+			Embeds:     &data.Embeds,
+			Components: &data.Components,
+		})
+		if err != nil {
+			log.Printf("Failed to update game view: %v", err)
+		}
+	} else if g.Interaction != nil {
 		_, err := s.InteractionResponseEdit(g.Interaction, &discordgo.WebhookEdit{
 			Embeds:     &g.RenderEmbed(s).Embeds,
 			Components: &g.RenderEmbed(s).Components,
@@ -365,3 +727,12 @@ func (g *Game) RenderUpdate(s *discordgo.Session) {
 		}
 	}
 }
+
+func containsPlayer(players []*Player, player *Player) bool {
+	for _, other := range players {
+		if other == player {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/bwmarrin/discordgo"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+const (
+	// Replay viewer buttons, followed by ":<replay id>:<step>"
+	ReplayPreviousButton string = "replay_previous"
+	ReplayNextButton     string = "replay_next"
+	// How long an opened replay can be stepped through
+	REPLAY_TTL time.Duration = time.Hour
+	// Largest replay file that will be downloaded
+	MAX_REPLAY_SIZE int64 = 8 << 20
+)
+
+var (
+	replays    = map[string]*engine.Replay{}
+	replaysMux = sync.Mutex{}
+)
+
+// Deal the first round from the game seed, or the committed deck order shuffled with the seed everyone contributed to.
+// Reseeding makes the deal independent of how the lobby was filled, so the replay can repeat it.
+func (g *Game) deal() {
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+
+	seed := g.Engine.Seed
+	base := int64(0)
+	if g.Fair != nil {
+		seed = g.Fair.Seed()
+		base = g.Fair.BaseSeed()
+	}
+	g.Engine.Reseed(seed)
+	if g.Fair != nil {
+		// Shuffle the committed order again with everyone's seeds
+		g.Engine.ResetFrom(g.Fair.Base(g.Engine.Rules, g.Engine.CustomDeck), g.HandSize)
+	} else {
+		g.Engine.Reset(g.HandSize, 0)
+	}
+	g.FairDeckHash = g.Engine.DeckHash
+
+	g.Replay = &engine.Replay{
+		Version:  engine.REPLAY_VERSION,
+		Seed:     seed,
+		Rules:    g.Engine.Rules,
+		Deck:     g.Engine.CustomDeck,
+		Base:     base,
+		HandSize: g.HandSize,
+	}
+	for _, player := range g.Players {
+		g.Replay.Players = append(g.Replay.Players, engine.ReplayPlayer{
+			ID:   player.User.ID,
+			Name: player.User.Username,
+			Bot:  player.IsBot(),
+			Team: player.Team,
+		})
+	}
+}
+
+// Add a step to the replay, callers hold engineMux so steps stay in order
+func (g *Game) recordStep(step engine.Step) {
+	if g.Replay != nil {
+		g.Replay.Steps = append(g.Replay.Steps, step)
+	}
+}
+
+// Attach the replay file to the end screen
+func (g *Game) attachReplay(s *discordgo.Session) {
+	if g.Replay == nil {
+		return
+	}
+
+	data, err := json.Marshal(g.Replay)
+	if err != nil {
+		log.Printf("Failed to export replay: %v", err)
+		return
+	}
+	file := &discordgo.File{
+		Name:        fmt.Sprintf("uno-replay-%s.json", g.ID),
+		ContentType: "application/json",
+		Reader:      bytes.NewReader(data),
+	}
+
+	if g.Interaction != nil {
+		_, err = s.InteractionResponseEdit(g.Interaction, &discordgo.WebhookEdit{
+			Files: []*discordgo.File{file},
+		})
+		if err == nil {
+			return
+		}
+	}
+
+	// Interaction token expired, post it on its own
+	file.Reader = bytes.NewReader(data)
+	_, err = s.ChannelMessageSendComplex(g.ChannelID, &discordgo.MessageSend{
+		Content: "📼 Replay of the last game, open it with `/uno replay`",
+		Files:   []*discordgo.File{file},
+	})
+	if err != nil {
+		log.Printf("Failed to send replay: %v", err)
+	}
+}
+
+// Open a replay file in the viewer
+func ShowReplay(s *discordgo.Session, i *discordgo.InteractionCreate, url string) {
+	replay, err := downloadReplay(url)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "That isn't an UNO replay file: " + err.Error(),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	id, _ := gonanoid.New()
+	replaysMux.Lock()
+	replays[id] = replay
+	replaysMux.Unlock()
+	time.AfterFunc(REPLAY_TTL, func() {
+		replaysMux.Lock()
+		delete(replays, id)
+		replaysMux.Unlock()
+	})
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: renderReplay(id, replay, 0),
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
+
+// Check if a button belongs to the replay viewer
+func IsReplayButton(customID string) bool {
+	return strings.HasPrefix(customID, ReplayPreviousButton+":") || strings.HasPrefix(customID, ReplayNextButton+":")
+}
+
+// Move the viewer to the step in the button
+func StepReplay(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	parts := strings.Split(customID, ":")
+	if len(parts) != 3 {
+		return
+	}
+	position, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return
+	}
+
+	replaysMux.Lock()
+	replay := replays[parts[1]]
+	replaysMux.Unlock()
+	if replay == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "This replay was closed, open the file again with `/uno replay`.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: renderReplay(parts[1], replay, position),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
+
+func downloadReplay(url string) (*engine.Replay, error) {
+	data, err := download(url, MAX_REPLAY_SIZE)
+	if err != nil {
+		return nil, err
+	}
+	return engine.ParseReplay(data)
+}
+
+// Read an attachment, at most limit bytes
+func download(url string, limit int64) ([]byte, error) {
+	client := http.Client{Timeout: 10 * time.Second}
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	return io.ReadAll(io.LimitReader(response.Body, limit))
+}
+
+// Render the game after position steps
+func renderReplay(id string, replay *engine.Replay, position int) *discordgo.InteractionResponseData {
+	if position < 0 {
+		position = 0
+	} else if position > len(replay.Steps) {
+		position = len(replay.Steps)
+	}
+
+	state, events, err := replay.Seek(position)
+
+	// A game to reuse the renderers with
+	g := &Game{Engine: state, State: Replaying}
+	for _, player := range replay.Players {
+		if seat := state.Player(player.ID); seat != nil {
+			g.Players = append(g.Players, &Player{
+				Player: seat,
+				User:   &discordgo.User{ID: player.ID, Username: player.Name, Bot: player.Bot},
+			})
+		}
+	}
+
+	var moves []string
+	for _, event := range events {
+		if text := g.describe(event); text != "" {
+			moves = append(moves, text)
+		}
+	}
+	description := "🃏 Cards dealt"
+	if position > 0 {
+		description = strings.Join(moves, "\n")
+	}
+	if err != nil {
+		description = "⚠️ The replay doesn't fit this deck from here on: " + err.Error()
+	}
+
+	fields := []*discordgo.MessageEmbedField{}
+	for _, player := range g.Players {
+		name := g.Mention(player.User.ID)
+		if g.GetCurrentPlayer() == player && state.Phase != engine.OverPhase {
+			name = "🎯 " + name
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s (%d)", player.User.Username, len(player.Hand)),
+			Value:  handSummary(name, player.Hand),
+			Inline: false,
+		})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("📼 UNO replay, step %d/%d", position, len(replay.Steps)),
+		Description: description,
+		Color:       0x00ff00,
+		Fields:      fields,
+		Image:       cardImage(state.TopCard()),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Seed %d, current card %s", replay.Seed, state.TopCard().Name),
+		},
+	}
+
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					&discordgo.Button{
+						Label:    "⬅️ Previous",
+						Style:    discordgo.SuccessButton,
+						CustomID: fmt.Sprintf("%s:%s:%d", ReplayPreviousButton, id, position-1),
+						Disabled: position <= 0,
+					},
+					&discordgo.Button{
+						Label:    "➡️ Next",
+						Style:    discordgo.SuccessButton,
+						CustomID: fmt.Sprintf("%s:%s:%d", ReplayNextButton, id, position+1),
+						Disabled: position >= len(replay.Steps) || err != nil,
+					},
+				},
+			},
+		},
+	}
+}
+
+// Helper function to list a hand in one field
+func handSummary(name string, hand []engine.Card) string {
+	cards := []string{name}
+	for _, card := range hand {
+		cards = append(cards, colorEmoji(card.Color)+card.Value)
+	}
+	summary := strings.Join(cards, " ")
+	// Drop whole cards, cutting bytes can split an emoji
+	for dropped := 1; len(summary) > 1024 && dropped < len(cards); dropped++ {
+		summary = strings.Join(cards[:len(cards)-dropped], " ") + fmt.Sprintf(" … +%d", dropped)
+	}
+	return summary
+}
@@ -0,0 +1,475 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/Ranzz02/uno-discord-bot/src/store"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Settings by guild ID
+	SettingsBucket string = "settings"
+	// /uno settings components, all start with "settings_"
+	SettingsRulesSelect    string = "settings_rules"
+	SettingsTimerSelect    string = "settings_timer"
+	SettingsAFKSelect      string = "settings_afk"
+	SettingsChannelsSelect string = "settings_channels"
+	SettingsLimitsButton   string = "settings_limits"
+	SettingsResetButton    string = "settings_reset"
+	SettingsDeckButton     string = "settings_deck"
+	SettingsLimitsModal    string = "settings_limits_modal"
+	// Limits modal inputs
+	HandSizeInput         string = "hand_size"
+	MaxPlayersInput       string = "max_players"
+	ColorTimeoutInput     string = "color_timeout"
+	ChallengeTimeoutInput string = "challenge_timeout"
+	KeepTimeoutInput      string = "keep_timeout"
+	// Bounds of the limits
+	MAX_HAND_SIZE      int           = 20
+	MAX_PLAYERS        int           = 10
+	MIN_PROMPT_TIMEOUT time.Duration = 5 * time.Second
+	MAX_PROMPT_TIMEOUT time.Duration = 2 * time.Minute
+	// Channels a channel select can hold
+	MAX_ALLOWED_CHANNELS int = 25
+	// Largest deck definition that will be downloaded
+	MAX_DECK_SIZE int64 = 1 << 20
+)
+
+var (
+	ErrChannelNotAllowed = errors.New("this server doesn't allow UNO games in this channel")
+	ErrCreateLobby       = errors.New("couldn't create the lobby")
+)
+
+// Defaults for new lobbies in a guild, changed by server managers with /uno settings
+type Settings struct {
+	Rules            engine.Rules
+	TurnTimeout      time.Duration
+	MaxTimeouts      int // Timeouts in a row before a player is removed, 0 never removes them
+	ColorTimeout     time.Duration
+	ChallengeTimeout time.Duration
+	KeepTimeout      time.Duration
+	HandSize         int
+	MaxPlayers       int
+	Channels         []string     // Channels games can be started in, empty allows every channel
+	Deck             *engine.Deck // Custom deck uploaded with /uno settings, nil plays with the default deck
+}
+
+// Settings of a guild that never changed them
+func DefaultSettings() Settings {
+	return Settings{
+		TurnTimeout:      DEFAULT_TURN_TIMEOUT,
+		MaxTimeouts:      DEFAULT_MAX_TIMEOUTS,
+		ColorTimeout:     DEFAULT_COLOR_TIMEOUT,
+		ChallengeTimeout: DEFAULT_CHALLENGE_TIMEOUT,
+		KeepTimeout:      DEFAULT_KEEP_TIMEOUT,
+		HandSize:         DEFAULT_HAND_SIZE,
+		MaxPlayers:       DEFAULT_MAX_PLAYERS,
+	}
+}
+
+// Settings of a guild, the defaults if it has none
+func LoadSettings(guildID string) Settings {
+	settings := DefaultSettings()
+	if guildID == "" {
+		return settings
+	}
+	if _, err := store.Get(SettingsBucket, guildID, &settings); err != nil {
+		log.Printf("Failed to load settings of %s: %v", guildID, err)
+		return DefaultSettings()
+	}
+	return settings
+}
+
+// Check if games can be started in the channel
+func (st Settings) Allows(channelID string) bool {
+	return len(st.Channels) == 0 || slices.Contains(st.Channels, channelID)
+}
+
+// Check hand size and player limit fit the deck, nil is the default deck
+func validateLimits(handSize int, maxPlayers int, deck *engine.Deck) error {
+	if handSize < 1 || handSize > MAX_HAND_SIZE {
+		return fmt.Errorf("starting hand size must be between 1 and %d", MAX_HAND_SIZE)
+	}
+	if maxPlayers < 2 || maxPlayers > MAX_PLAYERS {
+		return fmt.Errorf("max players must be between 2 and %d", MAX_PLAYERS)
+	}
+	// One card is turned over to start on
+	if deck == nil {
+		deck = engine.DefaultDeck
+	}
+	if size := deck.Size(); handSize*maxPlayers >= size {
+		return fmt.Errorf("%d players with %d cards each need more than the %d cards in the deck", maxPlayers, handSize, size)
+	}
+	return nil
+}
+
+// Use the settings for a lobby nobody joined yet
+func (g *Game) applySettings(settings Settings) {
+	// The deck depends on the rules
+	g.Engine = engine.NewWithDeck(settings.Rules, settings.Deck, g.Engine.Seed)
+	g.TurnTimeout = settings.TurnTimeout
+	g.MaxTimeouts = settings.MaxTimeouts
+	g.ColorTimeout = settings.ColorTimeout
+	g.ChallengeTimeout = settings.ChallengeTimeout
+	g.KeepTimeout = settings.KeepTimeout
+	g.HandSize = settings.HandSize
+	g.MaxPlayers = settings.MaxPlayers
+}
+
+// Check if a component belongs to the settings menu
+func IsSettingsComponent(customID string) bool {
+	return strings.HasPrefix(customID, "settings_")
+}
+
+// Only members who can manage the server change its settings
+func canManage(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	if i.Member != nil && i.Member.Permissions&discordgo.PermissionManageServer != 0 {
+		return true
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Content: "Only members who can manage the server can change UNO settings.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+	return false
+}
+
+// Open the settings menu, after switching to the deck definition at deckURL if there is one
+func ShowSettings(s *discordgo.Session, i *discordgo.InteractionCreate, deckURL string) {
+	if !canManage(s, i) {
+		return
+	}
+
+	settings := LoadSettings(i.GuildID)
+	if deckURL != "" {
+		deck, err := downloadDeck(deckURL)
+		if err == nil {
+			err = validateLimits(settings.HandSize, settings.MaxPlayers, deck)
+		}
+		if err == nil {
+			settings.Deck = deck
+			err = store.Put(SettingsBucket, i.GuildID, settings)
+		}
+		if err != nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Data: &discordgo.InteractionResponseData{
+					Content: "Deck not saved, " + err.Error() + ".",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+			})
+			return
+		}
+	}
+
+	data := renderSettings(settings)
+	data.Flags = discordgo.MessageFlagsEphemeral
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: data,
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
+
+// Change a setting from the settings menu
+func ChangeSettings(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) {
+	if !canManage(s, i) {
+		return
+	}
+
+	settings := LoadSettings(i.GuildID)
+	switch data.CustomID {
+	case SettingsRulesSelect:
+		settings.Rules = parseRules(data.Values)
+	case SettingsTimerSelect:
+		if len(data.Values) > 0 {
+			seconds, err := strconv.Atoi(data.Values[0])
+			if err == nil && seconds >= 0 {
+				settings.TurnTimeout = time.Duration(seconds) * time.Second
+			}
+		}
+	case SettingsAFKSelect:
+		if len(data.Values) > 0 {
+			limit, err := strconv.Atoi(data.Values[0])
+			if err == nil && limit >= 0 {
+				settings.MaxTimeouts = limit
+			}
+		}
+	case SettingsChannelsSelect:
+		settings.Channels = data.Values
+	case SettingsResetButton:
+		settings = DefaultSettings()
+	case SettingsDeckButton:
+		if err := validateLimits(settings.HandSize, settings.MaxPlayers, nil); err != nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Data: &discordgo.InteractionResponseData{
+					Content: "Settings not saved, " + err.Error() + ".",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+			})
+			return
+		}
+		settings.Deck = nil
+	case SettingsLimitsButton:
+		// Numbers are typed in a modal
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: limitsModal(settings),
+			Type: discordgo.InteractionResponseModal,
+		})
+		return
+	default:
+		return
+	}
+
+	saveSettings(s, i, settings)
+}
+
+// Change the limits and timers from the modal
+func ChangeLimits(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ModalSubmitInteractionData) {
+	if !canManage(s, i) {
+		return
+	}
+
+	values := map[string]int{}
+	for _, row := range data.Components {
+		actions, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actions.Components {
+			if input, ok := component.(*discordgo.TextInput); ok {
+				value, err := strconv.Atoi(strings.TrimSpace(input.Value))
+				if err != nil {
+					value = -1
+				}
+				values[input.CustomID] = value
+			}
+		}
+	}
+
+	settings := LoadSettings(i.GuildID)
+	settings.HandSize = values[HandSizeInput]
+	settings.MaxPlayers = values[MaxPlayersInput]
+	settings.ColorTimeout = time.Duration(values[ColorTimeoutInput]) * time.Second
+	settings.ChallengeTimeout = time.Duration(values[ChallengeTimeoutInput]) * time.Second
+	settings.KeepTimeout = time.Duration(values[KeepTimeoutInput]) * time.Second
+
+	err := validateLimits(settings.HandSize, settings.MaxPlayers, settings.Deck)
+	for _, timeout := range []time.Duration{settings.ColorTimeout, settings.ChallengeTimeout, settings.KeepTimeout} {
+		if err == nil && (timeout < MIN_PROMPT_TIMEOUT || timeout > MAX_PROMPT_TIMEOUT) {
+			err = fmt.Errorf("prompt timers must be between %d and %d seconds", int(MIN_PROMPT_TIMEOUT.Seconds()), int(MAX_PROMPT_TIMEOUT.Seconds()))
+		}
+	}
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "Settings not saved, " + err.Error() + ".",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	saveSettings(s, i, settings)
+}
+
+// Store the settings and show them in the menu
+func saveSettings(s *discordgo.Session, i *discordgo.InteractionCreate, settings Settings) {
+	if err := store.Put(SettingsBucket, i.GuildID, settings); err != nil {
+		log.Printf("Failed to save settings of %s: %v", i.GuildID, err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "Failed to save the settings, try again later.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: renderSettings(settings),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
+
+func renderSettings(settings Settings) *discordgo.InteractionResponseData {
+	turnTimer := "Off"
+	if settings.TurnTimeout > 0 {
+		turnTimer = settings.TurnTimeout.String()
+	}
+
+	afk := "Never removed"
+	if settings.MaxTimeouts > 0 {
+		afk = fmt.Sprintf("Removed after %d timeouts in a row", settings.MaxTimeouts)
+	}
+
+	channels := "Every channel"
+	if len(settings.Channels) > 0 {
+		var mentions []string
+		for _, channel := range settings.Channels {
+			mentions = append(mentions, "<#"+channel+">")
+		}
+		channels = strings.Join(mentions, ", ")
+	}
+
+	deck := fmt.Sprintf("Standard UNO deck, %d cards", engine.DefaultDeck.Size())
+	if settings.Deck != nil {
+		deck = fmt.Sprintf("Custom deck, %d cards", settings.Deck.Size())
+	}
+
+	var selected []discordgo.SelectMenuDefaultValue
+	for _, channel := range settings.Channels {
+		selected = append(selected, discordgo.SelectMenuDefaultValue{ID: channel, Type: discordgo.SelectMenuDefaultValueChannel})
+	}
+	minChannels := 0
+
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "⚙️ UNO settings",
+				Description: "Defaults for every new lobby on this server, the host can still change rules and the turn timer in the lobby. Upload a custom deck with the `deck` option of `/uno settings`.",
+				Color:       0x00ff00,
+				Fields: []*discordgo.MessageEmbedField{
+					rulesList(settings.Rules),
+					{Name: "Turn timer", Value: turnTimer, Inline: true},
+					{Name: "AFK players", Value: afk, Inline: true},
+					{Name: "Starting hand", Value: fmt.Sprintf("%d cards", settings.HandSize), Inline: true},
+					{Name: "Max players", Value: strconv.Itoa(settings.MaxPlayers), Inline: true},
+					{
+						Name:   "Prompt timers",
+						Value:  fmt.Sprintf("Color %s, challenge %s, keep %s", settings.ColorTimeout, settings.ChallengeTimeout, settings.KeepTimeout),
+						Inline: false,
+					},
+					{Name: "Allowed channels", Value: channels, Inline: false},
+					{Name: "Deck", Value: deck, Inline: false},
+				},
+			},
+		},
+		Components: []discordgo.MessageComponent{
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					rulesSelect(SettingsRulesSelect, settings.Rules),
+				},
+			},
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					timerSelect(SettingsTimerSelect, settings.TurnTimeout, settings.MaxTimeouts),
+				},
+			},
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					afkSelect(settings.MaxTimeouts),
+				},
+			},
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						MenuType:      discordgo.ChannelSelectMenu,
+						CustomID:      SettingsChannelsSelect,
+						Placeholder:   "💬 Allowed channels: every channel",
+						ChannelTypes:  []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						MinValues:     &minChannels,
+						MaxValues:     MAX_ALLOWED_CHANNELS,
+						DefaultValues: selected,
+					},
+				},
+			},
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					&discordgo.Button{
+						Label:    "Hand size, players and timers",
+						Style:    discordgo.PrimaryButton,
+						CustomID: SettingsLimitsButton,
+					},
+					&discordgo.Button{
+						Label:    "Use the standard deck",
+						Style:    discordgo.SecondaryButton,
+						CustomID: SettingsDeckButton,
+						Disabled: settings.Deck == nil,
+					},
+					&discordgo.Button{
+						Label:    "Reset to defaults",
+						Style:    discordgo.DangerButton,
+						CustomID: SettingsResetButton,
+					},
+				},
+			},
+		},
+	}
+}
+
+// Read and check a deck definition attachment
+func downloadDeck(url string) (*engine.Deck, error) {
+	data, err := download(url, MAX_DECK_SIZE)
+	if err != nil {
+		return nil, err
+	}
+	return engine.ParseDeck(data)
+}
+
+// Helper function to render the modal for the number settings
+func limitsModal(settings Settings) *discordgo.InteractionResponseData {
+	input := func(customID string, label string, value int) discordgo.MessageComponent {
+		return discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:  customID,
+					Label:     label,
+					Style:     discordgo.TextInputShort,
+					Value:     strconv.Itoa(value),
+					Required:  true,
+					MaxLength: 3,
+				},
+			},
+		}
+	}
+
+	return &discordgo.InteractionResponseData{
+		CustomID: SettingsLimitsModal,
+		Title:    "UNO limits and timers",
+		Components: []discordgo.MessageComponent{
+			input(HandSizeInput, fmt.Sprintf("Starting hand size (1-%d)", MAX_HAND_SIZE), settings.HandSize),
+			input(MaxPlayersInput, fmt.Sprintf("Max players (2-%d)", MAX_PLAYERS), settings.MaxPlayers),
+			input(ColorTimeoutInput, "Seconds to pick a color or swap", int(settings.ColorTimeout.Seconds())),
+			input(ChallengeTimeoutInput, "Seconds to challenge a wild draw card", int(settings.ChallengeTimeout.Seconds())),
+			input(KeepTimeoutInput, "Seconds to keep or play a drawn card", int(settings.KeepTimeout.Seconds())),
+		},
+	}
+}
+
+// Helper function to render the AFK limit picker
+func afkSelect(selected int) discordgo.SelectMenu {
+	var options []discordgo.SelectMenuOption
+	for _, limit := range MaxTimeoutChoices {
+		option := discordgo.SelectMenuOption{
+			Label:   fmt.Sprintf("Remove AFK players after %d timeouts in a row", limit),
+			Value:   strconv.Itoa(limit),
+			Default: selected == limit,
+		}
+		if limit == 1 {
+			option.Label = "Remove AFK players after their first timeout"
+		}
+		if limit == 0 {
+			option.Label = "Never remove AFK players"
+		}
+		options = append(options, option)
+	}
+
+	return discordgo.SelectMenu{
+		CustomID: SettingsAFKSelect,
+		Options:  options,
+	}
+}
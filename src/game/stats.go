@@ -0,0 +1,323 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/Ranzz02/uno-discord-bot/src/store"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Stats by "<guild id>/<user id>"
+	StatsBucket string = "stats"
+	// Leaderboard buttons, followed by ":<page>"
+	LeaderboardPreviousButton string = "leaderboard_previous"
+	LeaderboardNextButton     string = "leaderboard_next"
+	// Players on one leaderboard page
+	LEADERBOARD_PAGE_SIZE int = 10
+)
+
+var statsMux = sync.Mutex{}
+
+// Players record in one guild
+type Stats struct {
+	UserID        string
+	Name          string
+	Games         int
+	Wins          int
+	CardsLeft     int // Over every game, see AverageCardsLeft
+	WildDrawFours int
+	ChallengesWon int
+}
+
+func (st *Stats) WinRate() float64 {
+	if st.Games == 0 {
+		return 0
+	}
+	return float64(st.Wins) / float64(st.Games)
+}
+
+func (st *Stats) AverageCardsLeft() float64 {
+	if st.Games == 0 {
+		return 0
+	}
+	return float64(st.CardsLeft) / float64(st.Games)
+}
+
+// What a player did in one game, read from the replay
+type summary struct {
+	WildDrawFours int
+	ChallengesWon int
+	Drew          bool         // Drew any card after the deal
+	MostCards     int          // Largest hand they held
+	WonWith       *engine.Card // Last card of the last round they emptied their hand in
+}
+
+func statsKey(guildID string, userID string) string {
+	return guildID + "/" + userID
+}
+
+// Walk through the replay and sum up what every player did
+func (g *Game) summarize() map[string]*summary {
+	summaries := map[string]*summary{}
+	for _, player := range g.Players {
+		summaries[player.User.ID] = &summary{}
+	}
+	if g.Replay == nil {
+		return summaries
+	}
+
+	// Everyone starts with the dealt hand
+	for _, sum := range summaries {
+		sum.MostCards = g.Replay.HandSize
+	}
+
+	err := g.Replay.Walk(func(state *engine.State, step engine.Step, events []engine.Event) {
+		var played *engine.Card
+		for _, event := range events {
+			switch e := event.(type) {
+			case engine.CardPlayed:
+				played = &e.Card
+				if sum := summaries[e.Player]; sum != nil && e.Card.Type == engine.WildDrawFourCard {
+					sum.WildDrawFours++
+				}
+			case engine.CardsDrawn:
+				if sum := summaries[e.Player]; sum != nil {
+					sum.Drew = true
+				}
+			case engine.GameWon:
+				if sum := summaries[e.Player]; sum != nil {
+					sum.WonWith = played
+				}
+			case engine.ChallengeResolved:
+				// The challenged player wins when the challenge fails
+				winner := e.Challenged
+				if e.Won {
+					winner = e.Challenger
+				}
+				if sum := summaries[winner]; sum != nil {
+					sum.ChallengesWon++
+				}
+			}
+		}
+
+		for _, player := range state.Players {
+			if sum := summaries[player.ID]; sum != nil {
+				sum.MostCards = max(sum.MostCards, len(player.Hand))
+			}
+		}
+	})
+	if err != nil {
+		log.Printf("Failed to replay game %s for stats: %v", g.ID, err)
+	}
+	return summaries
+}
+
+// Check if the player or their team won the game
+func (g *Game) won(player *Player) bool {
+	return g.Winner != nil && (player == g.Winner || g.Engine.Teammates(player.Player, g.Winner.Player))
+}
+
+// Add the finished game to the stats of every human player
+func (g *Game) recordStats(summaries map[string]*summary) {
+	if g.GuildID == "" {
+		return
+	}
+
+	statsMux.Lock()
+	defer statsMux.Unlock()
+
+	for _, player := range g.Players {
+		if player.IsBot() {
+			continue
+		}
+
+		key := statsKey(g.GuildID, player.User.ID)
+		stats := &Stats{}
+		if _, err := store.Get(StatsBucket, key, stats); err != nil {
+			log.Printf("Failed to load stats of %s: %v", player.User.ID, err)
+			continue
+		}
+
+		stats.UserID = player.User.ID
+		stats.Name = player.User.Username
+		stats.Games++
+		if g.won(player) {
+			stats.Wins++
+		}
+		stats.CardsLeft += len(player.Hand)
+		if sum := summaries[player.User.ID]; sum != nil {
+			stats.WildDrawFours += sum.WildDrawFours
+			stats.ChallengesWon += sum.ChallengesWon
+		}
+
+		if err := store.Put(StatsBucket, key, stats); err != nil {
+			log.Printf("Failed to save stats of %s: %v", player.User.ID, err)
+		}
+	}
+}
+
+// Every player with stats in the guild, best first
+func guildStats(guildID string) ([]*Stats, error) {
+	var ranking []*Stats
+	err := store.ForEachPrefix(StatsBucket, guildID+"/", func(key string, data []byte) error {
+		stats := &Stats{}
+		if err := json.Unmarshal(data, stats); err != nil {
+			return err
+		}
+		ranking = append(ranking, stats)
+		return nil
+	})
+
+	sort.SliceStable(ranking, func(a, b int) bool {
+		if ranking[a].Wins != ranking[b].Wins {
+			return ranking[a].Wins > ranking[b].Wins
+		}
+		if ranking[a].WinRate() != ranking[b].WinRate() {
+			return ranking[a].WinRate() > ranking[b].WinRate()
+		}
+		return ranking[a].Games > ranking[b].Games
+	})
+	return ranking, err
+}
+
+// Show a players stats in the guild
+func ShowStats(s *discordgo.Session, i *discordgo.InteractionCreate, userID string) {
+	ranking, err := guildStats(i.GuildID)
+	if err != nil {
+		log.Printf("Failed to load stats: %v", err)
+	}
+
+	rank := 0
+	var stats *Stats
+	for index, other := range ranking {
+		if other.UserID == userID {
+			rank = index + 1
+			stats = other
+			break
+		}
+	}
+
+	if stats == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("<@%s> hasn't finished a game on this server yet.", userID),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       fmt.Sprintf("📊 Stats of %s", stats.Name),
+					Description: fmt.Sprintf("<@%s> is ranked **#%d** of %d on this server.", userID, rank, len(ranking)),
+					Color:       0x00ff00,
+					Fields: []*discordgo.MessageEmbedField{
+						{Name: "Games", Value: strconv.Itoa(stats.Games), Inline: true},
+						{Name: "Wins", Value: strconv.Itoa(stats.Wins), Inline: true},
+						{Name: "Win rate", Value: fmt.Sprintf("%.0f%%", stats.WinRate()*100), Inline: true},
+						{Name: "Average cards left", Value: fmt.Sprintf("%.1f", stats.AverageCardsLeft()), Inline: true},
+						{Name: "+4s played", Value: strconv.Itoa(stats.WildDrawFours), Inline: true},
+						{Name: "Challenges won", Value: strconv.Itoa(stats.ChallengesWon), Inline: true},
+					},
+				},
+			},
+		},
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
+
+// Show the first page of the guilds leaderboard
+func ShowLeaderboard(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: renderLeaderboard(i.GuildID, 0),
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+	})
+}
+
+// Check if a button belongs to a leaderboard
+func IsLeaderboardButton(customID string) bool {
+	return strings.HasPrefix(customID, LeaderboardPreviousButton+":") || strings.HasPrefix(customID, LeaderboardNextButton+":")
+}
+
+// Move the leaderboard to the page in the button
+func LeaderboardPage(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	_, value, _ := strings.Cut(customID, ":")
+	page, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: renderLeaderboard(i.GuildID, page),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
+
+func renderLeaderboard(guildID string, page int) *discordgo.InteractionResponseData {
+	ranking, err := guildStats(guildID)
+	if err != nil {
+		log.Printf("Failed to load leaderboard: %v", err)
+	}
+
+	totalPages := (len(ranking) + LEADERBOARD_PAGE_SIZE - 1) / LEADERBOARD_PAGE_SIZE
+	if page >= totalPages {
+		page = totalPages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	var lines []string
+	for index := page * LEADERBOARD_PAGE_SIZE; index < len(ranking) && index < (page+1)*LEADERBOARD_PAGE_SIZE; index++ {
+		stats := ranking[index]
+		lines = append(lines, fmt.Sprintf("`#%d` <@%s> **%d** wins in %d games (%.0f%%)", index+1, stats.UserID, stats.Wins, stats.Games, stats.WinRate()*100))
+	}
+	description := strings.Join(lines, "\n")
+	if len(lines) == 0 {
+		description = "Nobody finished a game on this server yet."
+	}
+
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "🏆 UNO leaderboard",
+				Description: description,
+				Color:       0x00ff00,
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: fmt.Sprintf("Page %d/%d", page+1, max(totalPages, 1)),
+				},
+			},
+		},
+		Components: []discordgo.MessageComponent{
+			&discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					&discordgo.Button{
+						Label:    "⬅️ Previous",
+						Style:    discordgo.SuccessButton,
+						CustomID: fmt.Sprintf("%s:%d", LeaderboardPreviousButton, page-1),
+						Disabled: page <= 0,
+					},
+					&discordgo.Button{
+						Label:    "➡️ Next",
+						Style:    discordgo.SuccessButton,
+						CustomID: fmt.Sprintf("%s:%d", LeaderboardNextButton, page+1),
+						Disabled: page >= totalPages-1,
+					},
+				},
+			},
+		},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+}
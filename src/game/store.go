@@ -0,0 +1,325 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Ranzz02/uno-discord-bot/src/ai"
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/Ranzz02/uno-discord-bot/src/store"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Games by channel ID
+	GamesBucket string = "games"
+	// Time between saves of every running game, games are also saved on shutdown
+	AUTOSAVE_INTERVAL time.Duration = 30 * time.Second
+)
+
+var (
+	ErrNoEngine  = errors.New("saved game has no engine state")
+	ErrNoMessage = errors.New("game has no message to edit")
+)
+
+// Game as it is stored, the channels and timers are recreated on restore
+type savedGame struct {
+	ID               string
+	ChannelID        string
+	GuildID          string
+	State            GameState
+	Host             string
+	Interaction      *savedInteraction
+	MessageID        string
+	Engine           *engine.State // Deck, discard pile, hands, turn and direction
+	Players          []savedPlayer
+	Winner           string
+	TargetScore      int
+	Scores           map[string]int
+	Round            int
+	TurnTimeout      time.Duration
+	MaxTimeouts      int
+	HandSize         int
+	MaxPlayers       int
+	ColorTimeout     time.Duration
+	ChallengeTimeout time.Duration
+	KeepTimeout      time.Duration
+	Fair             *engine.Fairness
+	FairDeckHash     string
+	Replay           *engine.Replay // Replayed on restore so the shuffles carry on where they stopped
+	JoinCode         string
+	Log              []LogEntry
+	UnoDeadline      time.Time
+}
+
+type savedPlayer struct {
+	ID          string
+	Name        string
+	Bot         bool
+	Role        PlayerRole
+	Interaction *savedInteraction // Hand view
+	Page        int
+	Timeouts    int
+	Difficulty  ai.Difficulty
+}
+
+// What it takes to edit an interaction response, tokens expire after 15 minutes
+type savedInteraction struct {
+	ID    string
+	AppID string
+	Token string
+}
+
+func saveInteraction(interaction *discordgo.Interaction) *savedInteraction {
+	if interaction == nil {
+		return nil
+	}
+	return &savedInteraction{ID: interaction.ID, AppID: interaction.AppID, Token: interaction.Token}
+}
+
+func (i *savedInteraction) interaction() *discordgo.Interaction {
+	if i == nil {
+		return nil
+	}
+	return &discordgo.Interaction{ID: i.ID, AppID: i.AppID, Token: i.Token}
+}
+
+// Write the game to the store
+func (g *Game) save() {
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+
+	saved := savedGame{
+		ID:               g.ID,
+		ChannelID:        g.ChannelID,
+		GuildID:          g.GuildID,
+		State:            g.State,
+		Host:             g.Host,
+		Interaction:      saveInteraction(g.Interaction),
+		MessageID:        g.MessageID,
+		Engine:           g.Engine,
+		TargetScore:      g.TargetScore,
+		Scores:           g.Scores,
+		Round:            g.Round,
+		TurnTimeout:      g.TurnTimeout,
+		MaxTimeouts:      g.MaxTimeouts,
+		HandSize:         g.HandSize,
+		MaxPlayers:       g.MaxPlayers,
+		ColorTimeout:     g.ColorTimeout,
+		ChallengeTimeout: g.ChallengeTimeout,
+		KeepTimeout:      g.KeepTimeout,
+		Fair:             g.Fair,
+		FairDeckHash:     g.FairDeckHash,
+		Replay:           g.Replay,
+		JoinCode:         g.JoinCode,
+		Log:              g.RecentLog(len(g.Log)),
+		UnoDeadline:      g.UnoDeadline,
+	}
+	if g.Winner != nil {
+		saved.Winner = g.Winner.User.ID
+	}
+	for _, player := range g.Players {
+		saved.Players = append(saved.Players, savedPlayer{
+			ID:          player.User.ID,
+			Name:        player.User.Username,
+			Bot:         player.IsBot(),
+			Role:        player.Role,
+			Interaction: saveInteraction(player.Interaction),
+			Page:        player.Page,
+			Timeouts:    player.Timeouts,
+			Difficulty:  player.Difficulty,
+		})
+	}
+
+	if err := store.Put(GamesBucket, g.ChannelID, saved); err != nil {
+		log.Printf("Failed to save game %s: %v", g.ID, err)
+	}
+}
+
+// Save every running game
+func SaveGames() {
+	gamesMux.Lock()
+	running := make([]*Game, 0, len(games))
+	for _, g := range games {
+		running = append(running, g)
+	}
+	gamesMux.Unlock()
+
+	for _, g := range running {
+		g.save()
+	}
+}
+
+// Save every running game until the bot shuts down
+func Autosave() {
+	for range time.Tick(AUTOSAVE_INTERVAL) {
+		SaveGames()
+	}
+}
+
+// Load the games that were running when the bot stopped and show them again
+func RestoreGames(s *discordgo.Session) {
+	var restored []*Game
+	var broken []string
+
+	err := store.ForEach(GamesBucket, func(channelID string, data []byte) error {
+		saved := &savedGame{}
+		if err := json.Unmarshal(data, saved); err != nil {
+			log.Printf("Dropping saved game in %s: %v", channelID, err)
+			broken = append(broken, channelID)
+			return nil
+		}
+		g, err := saved.restore()
+		if err != nil {
+			log.Printf("Dropping saved game %s: %v", saved.ID, err)
+			broken = append(broken, channelID)
+			return nil
+		}
+		restored = append(restored, g)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to load saved games: %v", err)
+	}
+
+	for _, channelID := range broken {
+		store.Delete(GamesBucket, channelID)
+	}
+	for _, g := range restored {
+		g.resume(s)
+	}
+	log.Printf("Restored %d games", len(restored))
+}
+
+// Rebuild a game from the store and register it
+func (saved *savedGame) restore() (*Game, error) {
+	state := saved.Engine
+	if state == nil {
+		return nil, ErrNoEngine
+	}
+
+	if saved.Replay != nil {
+		// Replaying brings back the shuffles, the saved state can't
+		replayed, _, err := saved.Replay.Seek(len(saved.Replay.Steps))
+		if err == nil {
+			state = replayed
+		} else {
+			log.Printf("Replay of game %s doesn't fit, using the saved state: %v", saved.ID, err)
+		}
+	}
+	if state == saved.Engine {
+		state.Reseed(state.Seed)
+	}
+	if state.Winner != nil {
+		state.Winner = state.Player(state.Winner.ID)
+	}
+
+	g := newLobby(saved.ChannelID, saved.Interaction.interaction(), saved.Host, state.Seed)
+	if g == nil {
+		return nil, ErrNoEngine
+	}
+	g.ID = saved.ID
+	g.GuildID = saved.GuildID
+	g.Engine = state
+	g.State = saved.State
+	g.MessageID = saved.MessageID
+	g.TargetScore = saved.TargetScore
+	g.Round = saved.Round
+	g.TurnTimeout = saved.TurnTimeout
+	g.MaxTimeouts = saved.MaxTimeouts
+	g.HandSize = saved.HandSize
+	g.MaxPlayers = saved.MaxPlayers
+	g.ColorTimeout = saved.ColorTimeout
+	g.ChallengeTimeout = saved.ChallengeTimeout
+	g.KeepTimeout = saved.KeepTimeout
+	g.Fair = saved.Fair
+	g.FairDeckHash = saved.FairDeckHash
+	g.Replay = saved.Replay
+	g.JoinCode = saved.JoinCode
+	g.Log = saved.Log
+	g.UnoDeadline = saved.UnoDeadline
+	if saved.Scores != nil {
+		g.Scores = saved.Scores
+	}
+
+	for _, player := range saved.Players {
+		seat := state.Player(player.ID)
+		if seat == nil {
+			continue
+		}
+		restored := &Player{
+			Player:      seat,
+			User:        &discordgo.User{ID: player.ID, Username: player.Name, Bot: player.Bot},
+			Role:        player.Role,
+			Interaction: player.Interaction.interaction(),
+			Page:        player.Page,
+			Timeouts:    player.Timeouts,
+		}
+		if player.Bot {
+			restored.Strategy = ai.New(player.Difficulty)
+			restored.Difficulty = player.Difficulty
+		}
+		g.Players = append(g.Players, restored)
+	}
+	g.Winner = g.GetPlayer(saved.Winner)
+
+	return g, nil
+}
+
+// Show a restored game again and pick up where it stopped
+func (g *Game) resume(s *discordgo.Session) {
+	if g.State == Playing {
+		g.record("♻️ The bot restarted, the game carries on.")
+	}
+	g.repost(s)
+	if g.State != Playing {
+		return
+	}
+
+	g.StartTurnTimer(s)
+	// The window to call UNO kept running while the bot was down
+	if g.Engine.UnoPending != "" {
+		g.expireUno(s, g.Engine.UnoPending, max(time.Until(g.UnoDeadline), 0))
+	}
+	g.RunBots(s)
+	go g.askWaiting(s)
+}
+
+// Edit the game message, or post a new one when the interaction token expired
+func (g *Game) repost(s *discordgo.Session) {
+	data := g.RenderEmbed(s)
+
+	var err error
+	switch {
+	case g.MessageID != "":
+		_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:    g.ChannelID,
+			ID:         g.MessageID,
+			Embeds:     &data.Embeds,
+			Components: &data.Components,
+		})
+	case g.Interaction != nil:
+		_, err = s.InteractionResponseEdit(g.Interaction, &discordgo.WebhookEdit{
+			Embeds:     &data.Embeds,
+			Components: &data.Components,
+		})
+	default:
+		err = ErrNoMessage
+	}
+	if err == nil {
+		return
+	}
+
+	message, err := s.ChannelMessageSendComplex(g.ChannelID, &discordgo.MessageSend{
+		Content:    "♻️ The bot restarted, the game continues here.",
+		Embeds:     data.Embeds,
+		Components: data.Components,
+	})
+	if err != nil {
+		log.Printf("Failed to repost game %s: %v", g.ID, err)
+		return
+	}
+	g.MessageID = message.ID
+}
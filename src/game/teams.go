@@ -0,0 +1,138 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var ErrUnevenTeams = errors.New("partners need an even number of players, at least 4, with 2 on every team")
+
+// Number of teams the lobby is split into, two players each
+func (g *Game) TeamCount() int {
+	count := (len(g.Players) + 1) / 2
+	if count < 2 {
+		count = 2
+	}
+	return count
+}
+
+// Put the player on the team with the fewest players
+func (g *Game) assignTeam(player *Player) {
+	sizes := g.teamSizes()
+	player.Team = 1
+	for team := 2; team <= g.TeamCount(); team++ {
+		if sizes[team] < sizes[player.Team] {
+			player.Team = team
+		}
+	}
+}
+
+// Spread every player over the teams in seat order
+func (g *Game) assignTeams() {
+	for _, player := range g.Players {
+		player.Team = 0
+	}
+	for _, player := range g.Players {
+		g.assignTeam(player)
+	}
+}
+
+// Players on each team
+func (g *Game) teamSizes() map[int]int {
+	sizes := map[int]int{}
+	for _, player := range g.Players {
+		if player.Team != 0 {
+			sizes[player.Team]++
+		}
+	}
+	return sizes
+}
+
+// Players on the same team as the player, the player included
+func (g *Game) Team(player *Player) []*Player {
+	if !g.Engine.Rules.Partners || player.Team == 0 {
+		return []*Player{player}
+	}
+
+	var team []*Player
+	for _, other := range g.Players {
+		if other.Team == player.Team {
+			team = append(team, other)
+		}
+	}
+	return team
+}
+
+// Key the player's points are kept under, partners share their score
+func (g *Game) scoreKey(player *Player) string {
+	if g.Engine.Rules.Partners && player.Team != 0 {
+		return fmt.Sprintf("team-%d", player.Team)
+	}
+	return player.User.ID
+}
+
+// Check the teams and seat partners opposite each other
+func (g *Game) arrangeTeams() error {
+	g.engineMux.Lock()
+	defer g.engineMux.Unlock()
+
+	count := len(g.Players) / 2
+	sizes := g.teamSizes()
+	if len(g.Players) < 4 || len(g.Players)%2 != 0 || len(sizes) != count {
+		return ErrUnevenTeams
+	}
+	for team := 1; team <= count; team++ {
+		if sizes[team] != 2 {
+			return ErrUnevenTeams
+		}
+	}
+
+	// First member of every team, then the second members in the same team order
+	seats := make([]*Player, len(g.Players))
+	filled := map[int]bool{}
+	for _, player := range g.Players {
+		seat := player.Team - 1
+		if filled[player.Team] {
+			seat += count
+		}
+		filled[player.Team] = true
+		seats[seat] = player
+	}
+
+	order := make([]string, len(seats))
+	for seat, player := range seats {
+		order[seat] = player.User.ID
+	}
+	g.Engine.Arrange(order)
+	g.Players = seats
+	return nil
+}
+
+// Move to the next team from the lobby
+func (g *Game) SwitchTeam(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	g.engineMux.Lock()
+	player := g.GetPlayer(i.Member.User.ID)
+	partners := g.Engine.Rules.Partners
+	if g.State == Lobby && player != nil && partners {
+		player.Team = player.Team%g.TeamCount() + 1
+	}
+	g.engineMux.Unlock()
+
+	if g.State != Lobby || player == nil || !partners {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Data: &discordgo.InteractionResponseData{
+				Content: "You can only switch teams in a partners lobby you joined.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Data: g.RenderEmbed(s),
+		Type: discordgo.InteractionResponseUpdateMessage,
+	})
+}
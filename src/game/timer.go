@@ -0,0 +1,98 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Ranzz02/uno-discord-bot/src/engine"
+	"github.com/bwmarrin/discordgo"
+)
+
+type TurnData struct {
+	Done     chan struct{}
+	Deadline time.Time
+}
+
+// Restart the turn timer for the current player
+func (g *Game) StartTurnTimer(s *discordgo.Session) {
+	g.StopTurnTimer()
+
+	g.timerMux.Lock()
+	defer g.timerMux.Unlock()
+
+	// Checked under timerMux, end() stops timers after changing the state so none outlive it
+	if g.TurnTimeout <= 0 || g.State != Playing {
+		return
+	}
+
+	g.engineMux.Lock()
+	current := g.GetCurrentPlayer()
+	prompting := g.Engine.Phase != engine.TurnPhase
+	g.engineMux.Unlock()
+	// Prompts run on their own clock
+	if prompting {
+		return
+	}
+
+	done := make(chan struct{})
+	g.TurnData.Done = done
+	g.TurnData.Deadline = time.Now().Add(g.TurnTimeout)
+
+	go g.WaitForTurn(s, current, done)
+}
+
+// Stop the running turn timer, if any
+func (g *Game) StopTurnTimer() {
+	g.timerMux.Lock()
+	defer g.timerMux.Unlock()
+
+	if g.TurnData.Done != nil {
+		close(g.TurnData.Done)
+		g.TurnData.Done = nil
+	}
+	g.TurnData.Deadline = time.Time{}
+}
+
+// Wait for the turn to end or time out
+func (g *Game) WaitForTurn(s *discordgo.Session, player *Player, done chan struct{}) {
+	select {
+	case <-done:
+		return
+	case <-time.After(g.TurnTimeout):
+		g.TimeoutTurn(s, player)
+	}
+}
+
+// Draw and keep a card for a player who let their turn run out, they are kicked after too many
+// timeouts in a row. Prompts run on their own clock, ColorTimeout, ChallengeTimeout and KeepTimeout,
+// so the turn timer never answers for a player who is still picking.
+func (g *Game) TimeoutTurn(s *discordgo.Session, player *Player) {
+	g.engineMux.Lock()
+	waiting := g.State == Playing && g.Engine.Phase == engine.TurnPhase
+	g.engineMux.Unlock()
+	if !waiting {
+		return
+	}
+
+	// Fails if the player made it in time after all
+	if err := g.applyAction(s, engine.DrawCard{Player: player.User.ID}); err != nil {
+		return
+	}
+	g.engineMux.Lock()
+	keep := g.Engine.Phase == engine.KeepPhase
+	g.engineMux.Unlock()
+	if keep {
+		g.applyAction(s, engine.KeepCard{Player: player.User.ID})
+	}
+	g.record(fmt.Sprintf("⏰ %s ran out of time and drew a card.", g.Mention(player.User.ID)))
+
+	if !player.IsBot() {
+		player.Timeouts++
+	}
+	if g.MaxTimeouts > 0 && player.Timeouts >= g.MaxTimeouts {
+		g.removePlayer(s, player.User.ID, "💤 %s was removed for being AFK.")
+		return
+	}
+
+	g.update(s)
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Embedded database for everything that has to survive a restart, values are stored as JSON
+var db *bolt.DB
+
+// Open the database file, creating it if it doesn't exist
+func Open(path string) error {
+	database, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return err
+	}
+	db = database
+	return nil
+}
+
+// Close the database, nothing is stored after this
+func Close() error {
+	if db == nil {
+		return nil
+	}
+	err := db.Close()
+	db = nil
+	return err
+}
+
+// Store a value under key, replacing what was there
+func Put(bucket string, key string, value any) error {
+	if db == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), data)
+	})
+}
+
+// Read the value under key, false if there is none
+func Get(bucket string, key string, value any) (bool, error) {
+	if db == nil {
+		return false, nil
+	}
+
+	var data []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(bucket)); b != nil {
+			data = b.Get([]byte(key))
+		}
+		if data != nil {
+			return json.Unmarshal(data, value)
+		}
+		return nil
+	})
+	return data != nil, err
+}
+
+// Remove the value under key
+func Delete(bucket string, key string) error {
+	if db == nil {
+		return nil
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+// Call fn with every key and raw value in the bucket, in key order
+func ForEach(bucket string, fn func(key string, data []byte) error) error {
+	if db == nil {
+		return nil
+	}
+
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(key []byte, data []byte) error {
+			return fn(string(key), data)
+		})
+	})
+}
+
+// Call fn with every key starting with prefix and its raw value, in key order
+func ForEachPrefix(bucket string, prefix string, fn func(key string, data []byte) error) error {
+	if db == nil {
+		return nil
+	}
+
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		cursor := b.Cursor()
+		for key, data := cursor.Seek([]byte(prefix)); key != nil && bytes.HasPrefix(key, []byte(prefix)); key, data = cursor.Next() {
+			if err := fn(string(key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}